@@ -0,0 +1,63 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderAtlas divides the geographic bounds of fullBounds into a grid of
+// rows by cols cells and writes one deck slide per cell, north row first,
+// each mapping its cell onto the whole screen box of fullBounds. A cell draws
+// every feature whose bounding box touches it, so features that cross an edge
+// appear on each page they reach, clipped at the cell edge (Config.ClipToScreen)
+// so that nothing bleeds from one page into the margin of the next. With
+// Config.Format SVG, each cell is a group instead of a slide.
+func RenderAtlas(dest io.Writer, r *shp.Reader, fullBounds Geometry, rows, cols int, c Config) error {
+	if rows < 1 || cols < 1 {
+		return fmt.Errorf("atlas: %d by %d is not a grid", rows, cols)
+	}
+	c.deleted = readerdeleted(r)
+	var recs []record
+	for r.Next() {
+		n, s := r.Shape()
+		recs = append(recs, record{row: n, shape: s})
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	c.ClipToScreen = true
+	w := (fullBounds.Longmax - fullBounds.Longmin) / float64(cols)
+	h := (fullBounds.Latmax - fullBounds.Latmin) / float64(rows)
+	for i := range rows {
+		for j := range cols {
+			cell := fullBounds
+			cell.Longmin = fullBounds.Longmin + float64(j)*w
+			cell.Longmax = cell.Longmin + w
+			cell.Latmax = fullBounds.Latmax - float64(i)*h
+			cell.Latmin = cell.Latmax - h
+			b := &recordBuffer{reader: r}
+			for _, rec := range recs {
+				if rec.shape != nil && cell.Intersects(boxgeo(rec.shape.BBox())) {
+					b.recs = append(b.recs, rec)
+				}
+			}
+			if c.Format == SVG {
+				c.begingroup(dest, fmt.Sprintf("cell %d,%d", i, j))
+			} else {
+				fmt.Fprintln(dest, "<slide>")
+			}
+			err := renderloop(dest, b, cell, c, nil, nil)
+			if c.Format == SVG {
+				c.endgroup(dest)
+			} else {
+				fmt.Fprintln(dest, "</slide>")
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
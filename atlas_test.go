@@ -0,0 +1,47 @@
+package shpdeck
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestRenderAtlas writes a slide per cell, north row first, with the
+// features that reach it clipped to the cell
+func TestRenderAtlas(t *testing.T) {
+	shapes := []shp.Shape{square(1, 1, 1), square(6, 6, 1), square(4, 0, 2), square(8, 1, 1)}
+	filename := writeShapefile(t, shp.POLYGON, shapes, nil, nil)
+	markdeleted(t, filename, 3)
+	var b strings.Builder
+	if err := RenderAtlas(&b, openShapefile(t, filename), unit, 2, 2, NewConfig("polygon", "red", 0)); err != nil {
+		t.Fatal(err)
+	}
+	slides := strings.Split(b.String(), "<slide>")[1:]
+	if len(slides) != 4 {
+		t.Fatalf("%d slides, want 4:\n%s", len(slides), b.String())
+	}
+	// the north west cell is empty, the north east has the square at 6,6,
+	// the south west the square at 1,1 and the left half of the one astride
+	// the cells, and the south east its right half, not the deleted square
+	want := [][]float64{nil, {20}, {20, 80}, {0}}
+	for i, slide := range slides {
+		if got := polygonx(t, slide); !slices.Equal(got, want[i]) {
+			t.Errorf("slide %d polygons at x %v, want %v", i, got, want[i])
+		}
+		for _, l := range strings.Split(slide, "\n") {
+			_, xs, _ := strings.Cut(l, ` xc="`)
+			xs, _, _ = strings.Cut(xs, `"`)
+			for _, f := range strings.Fields(xs) {
+				if x, _ := strconv.ParseFloat(f, 64); x < 0 || x > 100 {
+					t.Errorf("slide %d: x %g is off the screen", i, x)
+				}
+			}
+		}
+	}
+	if err := RenderAtlas(&b, openShapefile(t, filename), unit, 0, 2, NewConfig("polygon", "red", 0)); err == nil {
+		t.Error("no error for a grid without rows")
+	}
+}
@@ -0,0 +1,88 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// world maps the fixtures' -10..10 degrees onto a 0..100 screen box
+var world = Geometry{Xmin: 0, Xmax: 100, Ymin: 0, Ymax: 100, Longmin: -10, Longmax: 10, Latmin: -10, Latmax: 10}
+
+var benchsizes = []int{100, 10000}
+
+func BenchmarkPolygonCoords(b *testing.B) {
+	c := NewConfig("polygon", "steelblue", 0)
+	for _, n := range benchsizes {
+		poly := makePolygon(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				PolygonCoords(io.Discard, poly, world, c)
+			}
+		})
+	}
+}
+
+func BenchmarkPolylineCoords(b *testing.B) {
+	c := NewConfig("line", "black", 0.1)
+	for _, n := range benchsizes {
+		line := makePolyline(n, 100)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				PolylineCoords(io.Discard, line, world, c)
+			}
+		})
+	}
+}
+
+func BenchmarkMultipointCoords(b *testing.B) {
+	c := NewConfig("dot", "red", 0.5)
+	for _, n := range benchsizes {
+		mp := makeMultiPoint(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				MultipointCoords(io.Discard, mp, world, c)
+			}
+		})
+	}
+}
+
+func BenchmarkRenderShapes(b *testing.B) {
+	shapes := make([]shp.Shape, 1000)
+	for i := range shapes {
+		shapes[i] = makePolygon(100)
+	}
+	c := NewConfig("polygon", "tan", 0)
+	for b.Loop() {
+		if err := RenderShapes(io.Discard, shapes, world, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkChoropleth(b *testing.B) {
+	const n = 500
+	shapes := make([]shp.Shape, n)
+	rows := make([][]any, n)
+	for i := range shapes {
+		shapes[i] = square(float64(i%25)*0.8-10, float64(i/25)*0.8-10, 0.8)
+		rows[i] = []any{i}
+	}
+	filename := writeShapefile(b, shp.POLYGON, shapes, []shp.Field{shp.NumberField("POP", 10)}, rows)
+	cc := ChoroplethConfig{Field: "POP", Mode: Classed, Method: Quantile, Classes: 5, Palette: Palette{"#fff5f0", "#67000d"}}
+	c := NewConfig("polygon", "", 0)
+	for b.Loop() {
+		b.StopTimer()
+		r, err := shp.Open(filename)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := Choropleth(io.Discard, r, world, c, cc); err != nil {
+			b.Fatal(err)
+		}
+		r.Close()
+	}
+}
@@ -0,0 +1,60 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderBivariate colors each feature by two numeric fields at once.
+// Both fields are split into quantile classes: matrix[i][j] is the color for
+// class i of fieldY and class j of fieldX, with class 0 the lowest values, so
+// a 3x3 matrix gives a 3x3 bivariate map. Features missing either value are
+// drawn in the Config color.
+func RenderBivariate(dest io.Writer, r *shp.Reader, g Geometry, fieldX, fieldY string, matrix [][]string, c Config) error {
+	if len(matrix) == 0 || len(matrix[0]) == 0 {
+		return fmt.Errorf("bivariate: empty color matrix")
+	}
+	for i, row := range matrix {
+		if len(row) != len(matrix[0]) {
+			return fmt.Errorf("bivariate: matrix row %d has %d colors, want %d", i, len(row), len(matrix[0]))
+		}
+	}
+	fx, fy := fieldIndex(r, fieldX), fieldIndex(r, fieldY)
+	if fx < 0 {
+		return fmt.Errorf("bivariate: no field named %q", fieldX)
+	}
+	if fy < 0 {
+		return fmt.Errorf("bivariate: no field named %q", fieldY)
+	}
+	bx := Classify(fieldValues(r, fx, c.ParseValue), Quantile, len(matrix[0]))
+	by := Classify(fieldValues(r, fy, c.ParseValue), Quantile, len(matrix))
+	return renderloop(dest, r, g, c, nil, func(row int, fc Config) Config {
+		vx, okx := fieldValue(r, row, fx, c.ParseValue)
+		vy, oky := fieldValue(r, row, fy, c.ParseValue)
+		if okx && oky {
+			fc.color = matrix[ClassIndex(vy, by)][ClassIndex(vx, bx)]
+		}
+		return fc
+	})
+}
+
+// RenderBivariateLegend draws the color matrix as a grid of squares of the
+// given size with its lower left corner at (x, y), fieldX increasing to the
+// right and fieldY upward, with the axes labeled
+func RenderBivariateLegend(dest io.Writer, matrix [][]string, x, y, size float64, labelX, labelY string, c Config) {
+	for i, row := range matrix {
+		for j, color := range row {
+			fill, op := colorattr(color)
+			c.rect(dest, x+size*(float64(j)+0.5), y+size*(float64(i)+0.5), size, size, fill, op)
+		}
+	}
+	if len(matrix) == 0 {
+		return
+	}
+	ts := c.textstyle(TextStyle{Align: "center"})
+	w, h := size*float64(len(matrix[0])), size*float64(len(matrix))
+	c.text(dest, ts, x+w/2, y-ts.Size*1.5, labelX+" →", 0)
+	c.text(dest, ts, x-ts.Size, y+h/2, labelY+" →", 90)
+}
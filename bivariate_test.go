@@ -0,0 +1,57 @@
+package shpdeck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestRenderBivariate colors each feature by the classes of both fields,
+// and a feature missing either value in the Config color
+func TestRenderBivariate(t *testing.T) {
+	shapes := make([]shp.Shape, 6)
+	for i := range shapes {
+		shapes[i] = square(float64(i), 0, 1)
+	}
+	fields := []shp.Field{shp.StringField("X", 8), shp.StringField("Y", 8)}
+	rows := [][]any{{"1", "2"}, {"9", "1"}, {"2", "8"}, {"8", "9"}, {"5", ""}, {"n/a", "5"}}
+	r := openShapefile(t, writeShapefile(t, shp.POLYGON, shapes, fields, rows))
+	matrix := [][]string{{"red", "green"}, {"blue", "purple"}}
+	var b strings.Builder
+	if err := RenderBivariate(&b, r, unit, "X", "Y", matrix, NewConfig("polygon", "gray", 0)); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "<polygon ") {
+			_, rest, _ := strings.Cut(line, `color="`)
+			color, _, _ := strings.Cut(rest, `"`)
+			got = append(got, color)
+		}
+	}
+	if want := []string{"red", "green", "blue", "purple", "gray", "gray"}; !slices.Equal(got, want) {
+		t.Errorf("colors %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		fx, fy   string
+		matrix   [][]string
+		contains string
+	}{
+		{"empty matrix", "X", "Y", nil, "empty color matrix"},
+		{"ragged matrix", "X", "Y", [][]string{{"red", "green"}, {"blue"}}, "row 1 has 1 colors, want 2"},
+		{"no x field", "Z", "Y", matrix, `no field named "Z"`},
+		{"no y field", "X", "Z", matrix, `no field named "Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenderBivariate(&b, r, unit, tt.fx, tt.fy, tt.matrix, NewConfig("polygon", "gray", 0))
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("error %v, want %q", err, tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package shpdeck
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+)
+
+// sizeordered reads every record of r and returns them largest first,
+// by the area of their bounding boxes, keeping file order among equals
+func sizeordered(r records) records {
+	b := &recordBuffer{reader: attributes(r)}
+	for r.Next() {
+		n, s := r.Shape()
+		rec := record{row: n, shape: s}
+		if s != nil {
+			box := s.BBox()
+			rec.z = (box.MaxX - box.MinX) * (box.MaxY - box.MinY)
+		}
+		b.recs = append(b.recs, rec)
+	}
+	b.err = r.Err()
+	slices.SortStableFunc(b.recs, func(x, y record) int {
+		return cmp.Compare(y.z, x.z)
+	})
+	return b
+}
+
+// elements counts the markup elements in b, one per line, not counting comments
+func elements(b []byte) int {
+	n := 0
+	for line := range bytes.Lines(b) {
+		if !bytes.HasPrefix(line, []byte("<!--")) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// centerlineSamples is how many cross sections Centerline takes
+const centerlineSamples = 64
+
+// Centerline approximates the medial line of a long, thin polygon such as a
+// river. It finds the principal axis of the largest ring's vertices, cuts the
+// ring with evenly spaced cross sections perpendicular to that axis, and joins
+// the midpoints of the widest inside span of each section. This sampled
+// midline suits elongated shapes that do not bend back on themselves; it is
+// not a true medial axis.
+func Centerline(poly *shp.Polygon) []shp.Point {
+	var ring []shp.Point
+	for _, r := range partpoints(poly.Points, poly.Parts, poly.NumPoints) {
+		if math.Abs(signedArea(r)) > math.Abs(signedArea(ring)) {
+			ring = r
+		}
+	}
+	if len(ring) < 3 {
+		return nil
+	}
+	// principal axis from the covariance of the vertices
+	var mx, my float64
+	for _, p := range ring {
+		mx += p.X
+		my += p.Y
+	}
+	n := float64(len(ring))
+	mx, my = mx/n, my/n
+	var sxx, syy, sxy float64
+	for _, p := range ring {
+		dx, dy := p.X-mx, p.Y-my
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	theta := 0.5 * math.Atan2(2*sxy, sxx-syy)
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	// u runs along the axis, v across it
+	u := make([]float64, len(ring))
+	v := make([]float64, len(ring))
+	umin, umax := math.Inf(1), math.Inf(-1)
+	for i, p := range ring {
+		u[i] = p.X*cos + p.Y*sin
+		v[i] = -p.X*sin + p.Y*cos
+		umin, umax = min(umin, u[i]), max(umax, u[i])
+	}
+	var line []shp.Point
+	var cross []float64
+	step := (umax - umin) / centerlineSamples
+	for k := range centerlineSamples {
+		su := umin + step*(float64(k)+0.5)
+		cross = cross[:0]
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			if (u[i] > su) != (u[j] > su) {
+				cross = append(cross, v[i]+(v[j]-v[i])*(su-u[i])/(u[j]-u[i]))
+			}
+		}
+		sort.Float64s(cross)
+		best, mid := -1.0, 0.0
+		for c := 0; c+1 < len(cross); c += 2 {
+			if w := cross[c+1] - cross[c]; w > best {
+				best, mid = w, (cross[c]+cross[c+1])/2
+			}
+		}
+		if best >= 0 {
+			line = append(line, shp.Point{X: su*cos - mid*sin, Y: su*sin + mid*cos})
+		}
+	}
+	return line
+}
+
+// RenderCenterline draws the Centerline of a polygon as an open line
+func RenderCenterline(dest io.Writer, poly *shp.Polygon, g Geometry, c Config) {
+	c = c.withscreen(g)
+	line := Centerline(poly)
+	fill, op := colorattr(c.color)
+	for i := 1; i < len(line); i++ {
+		x1, y1 := c.mappoint(line[i-1], g)
+		x2, y2 := c.mappoint(line[i], g)
+		c.line(dest, x1, y1, x2, y2, fill, op, c.shapesize)
+	}
+}
@@ -0,0 +1,42 @@
+package shpdeck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestCenterline samples the midline of the largest ring along its long axis
+func TestCenterline(t *testing.T) {
+	strip := ring(shp.Point{X: 0, Y: 0}, shp.Point{X: 0, Y: 1}, shp.Point{X: 10, Y: 1}, shp.Point{X: 10, Y: 0})
+	island := ring(shp.Point{X: 20, Y: 20}, shp.Point{X: 20, Y: 21}, shp.Point{X: 21, Y: 21}, shp.Point{X: 21, Y: 20})
+	diagonal := ring(shp.Point{X: 0, Y: 1}, shp.Point{X: 9, Y: 10}, shp.Point{X: 10, Y: 9}, shp.Point{X: 1, Y: 0})
+	tests := []struct {
+		name  string
+		rings [][]shp.Point
+		off   func(p shp.Point) float64 // distance from the true midline
+		span  float64                   // length of the midline
+	}{
+		{"strip", [][]shp.Point{strip}, func(p shp.Point) float64 { return p.Y - 0.5 }, 10},
+		{"strip and island", [][]shp.Point{island, strip}, func(p shp.Point) float64 { return p.Y - 0.5 }, 10},
+		{"diagonal", [][]shp.Point{diagonal}, func(p shp.Point) float64 { return (p.X - p.Y) / math.Sqrt2 }, 9 * math.Sqrt2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poly := shp.Polygon(*shp.NewPolyLine(tt.rings))
+			line := Centerline(&poly)
+			if len(line) != centerlineSamples {
+				t.Fatalf("%d points, want %d", len(line), centerlineSamples)
+			}
+			for _, p := range line {
+				if math.Abs(tt.off(p)) > 1e-9 {
+					t.Errorf("%v is off the midline", p)
+				}
+			}
+			if l := math.Hypot(line[0].X-line[len(line)-1].X, line[0].Y-line[len(line)-1].Y); math.Abs(l-tt.span) > tt.span/centerlineSamples+1e-9 {
+				t.Errorf("midline runs %g, want about %g", l, tt.span)
+			}
+		})
+	}
+}
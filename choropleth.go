@@ -0,0 +1,450 @@
+package shpdeck
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Palette is an ordered list of colors used as a ramp, low to high
+type Palette []string
+
+// ChoroplethMode selects how a data value is turned into a color
+type ChoroplethMode int
+
+const (
+	// HueRamp interpolates along the palette
+	HueRamp ChoroplethMode = iota
+	// OpacityRamp keeps a fixed color and varies the opacity
+	OpacityRamp
+	// Classed colors each class (see Classify) with one palette color
+	Classed
+)
+
+// ChoroplethConfig describes how a DBF field drives the fill color of each feature
+type ChoroplethConfig struct {
+	Field      string         // name of the numeric DBF field
+	Min, Max   float64        // value range; computed from the data when Min == Max
+	Mode       ChoroplethMode // HueRamp or OpacityRamp
+	Palette    Palette        // colors for HueRamp
+	Color      string         // fixed color for OpacityRamp
+	MinOpacity float64        // opacity floor (0-100) for OpacityRamp
+	Breaks     []float64      // class bounds for Classed; computed from the data when empty
+	Method     ClassMethod    // how to compute the Breaks
+	Classes    int            // how many classes to compute
+
+	// Percentiles, such as {5, 95}, clamps a range computed from the data to
+	// those percentiles (0-100) of the values, so outliers saturate the ends
+	// of the ramp instead of compressing the rest; zero uses the full range
+	Percentiles [2]float64
+
+	// features whose field is blank or not a number are drawn in NoDataColor
+	// (default the Config color) overlaid with NoDataPattern, if set (see Config.FillPattern)
+	NoDataColor   string
+	NoDataPattern string
+
+	// ClassBoundaries, when its Color is set, emphasizes the edges between
+	// neighboring features of different classes in a Classed choropleth,
+	// drawn over the fills. Neighbors are found by the edges they share,
+	// vertex for vertex, so the data must be topologically clean.
+	ClassBoundaries Stroke
+}
+
+// Color returns the color at t (0-1) along the palette.
+// Hex colors (#rgb, #rrggbb) and CSS color names are interpolated in linear
+// RGB, other names snap to the nearest entry.
+func (p Palette) Color(t float64) string {
+	switch len(p) {
+	case 0:
+		return ""
+	case 1:
+		return p[0]
+	}
+	t = clamp(t, 0, 1)
+	f := t * float64(len(p)-1)
+	i := int(f)
+	if i >= len(p)-1 {
+		return p[len(p)-1]
+	}
+	return lerpcolor(p[i], p[i+1], f-float64(i))
+}
+
+// clamp restricts v to the interval [low, high]
+func clamp(v, low, high float64) float64 {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+// hexrgb parses #rgb or #rrggbb into components
+func hexrgb(s string) (r, g, b uint8, ok bool) {
+	if !strings.HasPrefix(s, "#") {
+		return 0, 0, 0, false
+	}
+	s = s[1:]
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// lerpcolor blends two colors, t=0 is a, t=1 is b. Hex and CSS named colors
+// blend in linear RGB, which avoids the dark, muddy midpoints of blending sRGB
+// values directly; opacities given as "name:op" blend as well.
+// Other names snap to the nearer color.
+func lerpcolor(a, b string, t float64) string {
+	an, aop := ColorOp(a)
+	bn, bop := ColorOp(b)
+	ac, aok := NamedColor(an)
+	bc, bok := NamedColor(bn)
+	if !aok || !bok {
+		if t < 0.5 {
+			return a
+		}
+		return b
+	}
+	mix := func(x, y uint8) uint8 {
+		lx, ly := srgbToLinear(x), srgbToLinear(y)
+		return linearToSRGB(lx + (ly-lx)*t)
+	}
+	s := fmt.Sprintf("#%02x%02x%02x", mix(ac.R, bc.R), mix(ac.G, bc.G), mix(ac.B, bc.B))
+	if strings.Contains(a, ":") || strings.Contains(b, ":") {
+		ao, _ := strconv.ParseFloat(aop, 64)
+		bo, _ := strconv.ParseFloat(bop, 64)
+		s += fmt.Sprintf(":%.0f", ao+(bo-ao)*t)
+	}
+	return s
+}
+
+// srgbToLinear converts an sRGB component to linear light (0-1)
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts linear light (0-1) to an sRGB component
+func linearToSRGB(l float64) uint8 {
+	l = clamp(l, 0, 1)
+	c := l * 12.92
+	if l > 0.0031308 {
+		c = 1.055*math.Pow(l, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(c * 255))
+}
+
+// fieldIndex returns the index of the named DBF field, or -1 if not found
+func fieldIndex(r *shp.Reader, name string) int {
+	for i, f := range r.Fields() {
+		if strings.EqualFold(f.String(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseValue is the default parsing of numeric attributes: strconv.ParseFloat
+// of the value with surrounding spaces (and the NULs that pad some writers'
+// fields) trimmed
+func ParseValue(raw string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.Trim(raw, " \x00"), 64)
+	return v, err == nil
+}
+
+// fieldValue reads a numeric attribute with parse, or ParseValue when parse is nil
+func fieldValue(r *shp.Reader, row, field int, parse func(string) (float64, bool)) (float64, bool) {
+	if parse == nil {
+		parse = ParseValue
+	}
+	return parse(r.ReadAttribute(row, field))
+}
+
+// fieldValues reads every parseable value of a numeric field, leaving out deleted records
+func fieldValues(r *shp.Reader, field int, parse func(string) (float64, bool)) []float64 {
+	var values []float64
+	deleted := readerdeleted(r)
+	for row := range r.AttributeCount() {
+		if isdeleted(deleted, row) {
+			continue
+		}
+		if v, ok := fieldValue(r, row, field, parse); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// fieldRange finds the minimum and maximum values of a numeric field,
+// leaving out deleted records
+func fieldRange(r *shp.Reader, field int, parse func(string) (float64, bool)) (float64, float64) {
+	lo, hi := 0.0, 0.0
+	first := true
+	deleted := readerdeleted(r)
+	for row := range r.AttributeCount() {
+		v, ok := fieldValue(r, row, field, parse)
+		if !ok || isdeleted(deleted, row) {
+			continue
+		}
+		if first || v < lo {
+			lo = v
+		}
+		if first || v > hi {
+			hi = v
+		}
+		first = false
+	}
+	return lo, hi
+}
+
+// ramp returns the position (0-1) of v within [min, max]
+func ramp(v, min, max float64) float64 {
+	if max == min {
+		return 0
+	}
+	return clamp((v-min)/(max-min), 0, 1)
+}
+
+// ColorForValue returns the palette color for v within the range [min, max]
+func ColorForValue(v, min, max float64, pal Palette) string {
+	return pal.Color(ramp(v, min, max))
+}
+
+// ColorForCategory returns a palette color for a category value.
+// The same value always gets the same color from the same palette.
+func ColorForCategory(s string, pal Palette) string {
+	if len(pal) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return pal[h.Sum32()%uint32(len(pal))]
+}
+
+// ClassColor returns the palette color of class i of n, spreading the classes
+// evenly along the palette from its first color to its last
+func ClassColor(i, n int, pal Palette) string {
+	if n < 2 {
+		return pal.Color(0)
+	}
+	return pal.Color(float64(i) / float64(n-1))
+}
+
+// color returns the choropleth color for a value
+func (cc ChoroplethConfig) color(v float64) string {
+	if cc.Mode == Classed {
+		return ClassColor(ClassIndex(v, cc.Breaks), len(cc.Breaks)-1, cc.Palette)
+	}
+	if cc.Mode == OpacityRamp {
+		fill, _ := ColorOp(cc.Color)
+		floor := clamp(cc.MinOpacity, 0, 100)
+		return fmt.Sprintf("%s:%.0f", fill, floor+(100-floor)*ramp(v, cc.Min, cc.Max))
+	}
+	return ColorForValue(v, cc.Min, cc.Max, cc.Palette)
+}
+
+// valuerange is the range of a numeric field, or of its percentiles p when set
+func valuerange(r *shp.Reader, field int, p [2]float64, parse func(string) (float64, bool)) (float64, float64) {
+	if p == [2]float64{} {
+		return fieldRange(r, field, parse)
+	}
+	values := fieldValues(r, field, parse)
+	if len(values) == 0 {
+		return 0, 0
+	}
+	slices.Sort(values)
+	return percentile(values, p[0]/100), percentile(values, p[1]/100)
+}
+
+// ChoroplethRange returns the value range Choropleth colors over, Min and Max
+// or else computed from the data with Config.ParseValue, for a legend
+func ChoroplethRange(r *shp.Reader, c Config, cc ChoroplethConfig) (float64, float64, error) {
+	field := fieldIndex(r, cc.Field)
+	if field < 0 {
+		return 0, 0, fmt.Errorf("choropleth: no field named %q", cc.Field)
+	}
+	if cc.Min != cc.Max {
+		return cc.Min, cc.Max, nil
+	}
+	lo, hi := valuerange(r, field, cc.Percentiles, c.ParseValue)
+	return lo, hi, nil
+}
+
+// Choropleth renders every feature of the shapefile, colored by the value
+// of a DBF field. Features without a parseable value are still drawn,
+// in the no-data style.
+func Choropleth(dest io.Writer, r *shp.Reader, g Geometry, c Config, cc ChoroplethConfig) error {
+	field := fieldIndex(r, cc.Field)
+	if field < 0 {
+		return fmt.Errorf("choropleth: no field named %q", cc.Field)
+	}
+	if cc.Min == cc.Max {
+		cc.Min, cc.Max = valuerange(r, field, cc.Percentiles, c.ParseValue)
+	}
+	if cc.Mode == Classed && len(cc.Breaks) == 0 {
+		cc.Breaks = Classify(fieldValues(r, field, c.ParseValue), cc.Method, cc.Classes)
+	}
+	style := func(row int, fc Config) Config {
+		if v, ok := fieldValue(r, row, field, c.ParseValue); ok {
+			fc.color = cc.color(v)
+			return fc
+		}
+		if cc.NoDataColor != "" {
+			fc.color = cc.NoDataColor
+		}
+		if cc.NoDataPattern != "" {
+			fc.FillPattern = cc.NoDataPattern
+		}
+		return fc
+	}
+	if cc.Mode != Classed || cc.ClassBoundaries.Color == "" {
+		return renderloop(dest, r, g, c, nil, style)
+	}
+	b := &recordBuffer{reader: r}
+	for r.Next() {
+		n, s := r.Shape()
+		b.recs = append(b.recs, record{row: n, shape: s})
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	if err := renderloop(dest, b, g, c, nil, style); err != nil {
+		return err
+	}
+	renderclassboundaries(dest, b.recs, func(row int) int {
+		if v, ok := fieldValue(r, row, field, c.ParseValue); ok {
+			return ClassIndex(v, cc.Breaks)
+		}
+		return -1
+	}, g, cc.ClassBoundaries, c)
+	return nil
+}
+
+// CategoryConfig describes how a text DBF field picks the fill color of each feature
+type CategoryConfig struct {
+	Field   string  // name of the DBF field
+	Palette Palette // colors assigned to categories
+	TopN    int     // if > 0, only the TopN most frequent categories get their own color
+	Other   string  // color of the remaining categories with TopN; empty uses "gray"
+
+	Colors map[string]string // fixed colors of some categories (see WithCategoryColors)
+}
+
+// OtherCategory is the key of the bucket for categories beyond TopN
+const OtherCategory = "Other"
+
+// CategoryColors returns the color of each category in the field, for a legend.
+// Seeded categories keep their colors, and every other category gets the
+// ColorForCategory of the palette colors left free, so a category has the
+// same color in every map with the same palette and seeds. With TopN, only
+// the most frequent categories are colored this way, and the rest share the
+// Other color under the key OtherCategory.
+func CategoryColors(r *shp.Reader, cat CategoryConfig) (map[string]string, error) {
+	field := fieldIndex(r, cat.Field)
+	if field < 0 {
+		return nil, fmt.Errorf("categorical: no field named %q", cat.Field)
+	}
+	counts := map[string]int{}
+	deleted := readerdeleted(r)
+	for row := range r.AttributeCount() {
+		if isdeleted(deleted, row) {
+			continue
+		}
+		if v := r.ReadAttribute(row, field); v != "" {
+			if _, seeded := cat.Colors[v]; !seeded {
+				counts[v]++
+			}
+		}
+	}
+	colors := make(map[string]string, len(counts)+len(cat.Colors))
+	for v, color := range cat.Colors {
+		colors[v] = color
+	}
+	// the other categories take the palette colors the seeded ones left free
+	used := slices.Collect(maps.Values(cat.Colors))
+	free := make(Palette, 0, len(cat.Palette))
+	for _, color := range cat.Palette {
+		if !slices.Contains(used, color) {
+			free = append(free, color)
+		}
+	}
+	if len(free) == 0 {
+		free = cat.Palette
+	}
+	if cat.TopN <= 0 || len(counts) <= cat.TopN {
+		for v := range counts {
+			colors[v] = ColorForCategory(v, free)
+		}
+		return colors, nil
+	}
+	ranked := make([]string, 0, len(counts))
+	for v := range counts {
+		ranked = append(ranked, v)
+	}
+	// most frequent first, ties by name so the assignment is stable
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] != counts[ranked[j]] {
+			return counts[ranked[i]] > counts[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	other := cat.Other
+	if other == "" {
+		other = "gray"
+	}
+	for i, v := range ranked {
+		if i >= cat.TopN {
+			colors[v] = other
+		} else {
+			colors[v] = ColorForCategory(v, free)
+		}
+	}
+	colors[OtherCategory] = other
+	return colors, nil
+}
+
+// WithCategoryColors returns cat with fixed colors for the given categories,
+// so that maps sharing them color the same categories alike, whichever
+// appear in each file. The seeded categories are always in the mapping
+// CategoryColors returns, and do not count toward TopN.
+func (cat CategoryConfig) WithCategoryColors(colors map[string]string) CategoryConfig {
+	cat.Colors = maps.Clone(colors)
+	return cat
+}
+
+// Categorical renders every feature of the shapefile, colored by the category
+// named in a DBF field (see CategoryColors). Features with an empty value use
+// the Config color.
+func Categorical(dest io.Writer, r *shp.Reader, g Geometry, c Config, cat CategoryConfig) error {
+	field := fieldIndex(r, cat.Field)
+	colors, err := CategoryColors(r, cat)
+	if err != nil {
+		return err
+	}
+	return renderloop(dest, r, g, c, nil, func(row int, fc Config) Config {
+		if v := r.ReadAttribute(row, field); v != "" {
+			fc.color = colors[v]
+		}
+		return fc
+	})
+}
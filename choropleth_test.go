@@ -0,0 +1,203 @@
+package shpdeck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// attributed writes a polygon shapefile with one square per value of field
+func attributed(tb testing.TB, field string, values ...string) *shp.Reader {
+	tb.Helper()
+	shapes := make([]shp.Shape, len(values))
+	rows := make([][]any, len(values))
+	for i, v := range values {
+		shapes[i] = square(float64(i), 0, 1)
+		rows[i] = []any{v}
+	}
+	return openShapefile(tb, writeShapefile(tb, shp.POLYGON, shapes, []shp.Field{shp.StringField(field, 16)}, rows))
+}
+
+func TestCategoryColors(t *testing.T) {
+	pal := Palette{"red", "green", "blue", "orange", "purple"}
+	seeds := map[string]string{"Left": "red", "Right": "blue"}
+	values := []string{"Left", "Right", "Green", "Green", "Green", "Labour", "Labour", "Pirate", "Liberal"}
+	tests := []struct {
+		name string
+		topN int
+	}{
+		{"all categories", 0},
+		{"top n covers all", 4},
+		{"top n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat := CategoryConfig{Field: "C", Palette: pal, TopN: tt.topN}.WithCategoryColors(seeds)
+			colors, err := CategoryColors(attributed(t, "C", values...), cat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the same categories in another file, most of the others absent
+			alone, err := CategoryColors(attributed(t, "C", "Green", "Labour"), cat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for v, color := range colors {
+				if _, seeded := seeds[v]; seeded {
+					if color != seeds[v] {
+						t.Errorf("seeded %s is %s, want %s", v, color, seeds[v])
+					}
+					continue
+				}
+				if v == OtherCategory || color == "gray" {
+					continue
+				}
+				if color == "red" || color == "blue" {
+					t.Errorf("%s takes the seeded color %s", v, color)
+				}
+				if want := ColorForCategory(v, Palette{"green", "orange", "purple"}); color != want {
+					t.Errorf("%s is %s, want %s", v, color, want)
+				}
+				if a, ok := alone[v]; ok && a != color {
+					t.Errorf("%s is %s in one map and %s in another", v, color, a)
+				}
+			}
+			if tt.topN == 2 && (colors["Green"] == "gray" || colors["Labour"] == "gray" || colors["Pirate"] != "gray") {
+				t.Errorf("top 2 colors = %v", colors)
+			}
+		})
+	}
+}
+
+func TestChoroplethRange(t *testing.T) {
+	percent := func(s string) (float64, bool) {
+		return ParseValue(strings.TrimSuffix(s, "%"))
+	}
+	tests := []struct {
+		name   string
+		parse  func(string) (float64, bool)
+		cc     ChoroplethConfig
+		lo, hi float64
+	}{
+		{"default parsing", nil, ChoroplethConfig{Field: "P"}, 5, 7},
+		{"config parsing", percent, ChoroplethConfig{Field: "P"}, 5, 40},
+		{"fixed range", percent, ChoroplethConfig{Field: "P", Min: -1, Max: 1}, -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.ParseValue = tt.parse
+			lo, hi, err := ChoroplethRange(attributed(t, "P", "5", "12%", "7", "40%"), c, tt.cc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lo != tt.lo || hi != tt.hi {
+				t.Errorf("range %v..%v, want %v..%v", lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestChoroplethNoData(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"color", ""},
+		{"color and hatching", PatternHatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := ChoroplethConfig{Field: "P", Palette: Palette{"yellow", "red"}, NoDataColor: "gray", NoDataPattern: tt.pattern}
+			var b strings.Builder
+			if err := Choropleth(&b, attributed(t, "P", "5", "", "n/a", "10"), unit, NewConfig("polygon", "blue", 0.1), cc); err != nil {
+				t.Fatal(err)
+			}
+			// each polygon and the hatch lines drawn over it
+			var colors []string
+			hatched := map[int]bool{}
+			for _, line := range strings.Split(b.String(), "\n") {
+				switch {
+				case strings.HasPrefix(line, "<polygon "):
+					_, rest, _ := strings.Cut(line, `color="`)
+					color, _, _ := strings.Cut(rest, `"`)
+					colors = append(colors, color)
+				case strings.HasPrefix(line, "<line "):
+					hatched[len(colors)-1] = true
+				}
+			}
+			if len(colors) != 4 {
+				t.Fatalf("%d polygons, want 4:\n%s", len(colors), b.String())
+			}
+			for i, nodata := range []bool{false, true, true, false} {
+				if got := colors[i] == "gray"; got != nodata {
+					t.Errorf("record %d is %s", i, colors[i])
+				}
+				if want := nodata && tt.pattern != ""; hatched[i] != want {
+					t.Errorf("record %d hatched: %v, want %v", i, hatched[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPaletteColor(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Palette
+		t    float64
+		want string
+	}{
+		// half of full linear light is sRGB 0xbc, not the muddy 0x80
+		{"red to blue midpoint", Palette{"red", "blue"}, 0.5, "#bc00bc"},
+		{"black to white midpoint", Palette{"#000", "#fff"}, 0.5, "#bcbcbc"},
+		{"ends", Palette{"red", "blue"}, 1, "blue"},
+		{"clamped", Palette{"red", "blue"}, -1, "#ff0000"},
+		{"three stops", Palette{"black", "white", "black"}, 0.75, "#bcbcbc"},
+		{"opacity", Palette{"red:0", "blue:100"}, 0.5, "#bc00bc:50"},
+		{"unknown names snap", Palette{"wood", "stone"}, 0.6, "stone"},
+		{"single", Palette{"red"}, 0.5, "red"},
+		{"empty", nil, 0.5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Color(tt.t); got != tt.want {
+				t.Errorf("Color(%v) = %q, want %q", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestChoroplethModes ramps the hue along the palette or the opacity of one
+// color over the range of the field
+func TestChoroplethModes(t *testing.T) {
+	tests := []struct {
+		name string
+		cc   ChoroplethConfig
+		want []string
+	}{
+		{"hue", ChoroplethConfig{Field: "P", Palette: Palette{"#000000", "#ffffff"}}, []string{`color="#000000" opacity="100"`, `color="#bcbcbc" opacity="100"`, `color="#ffffff" opacity="100"`}},
+		{"opacity", ChoroplethConfig{Field: "P", Mode: OpacityRamp, Color: "red", MinOpacity: 20}, []string{`color="red" opacity="20"`, `color="red" opacity="60"`, `color="red" opacity="100"`}},
+		{"opacity over a set range", ChoroplethConfig{Field: "P", Mode: OpacityRamp, Color: "red", Min: 0, Max: 20}, []string{`color="red" opacity="0"`, `color="red" opacity="25"`, `color="red" opacity="50"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Choropleth(&b, attributed(t, "P", "0", "5", "10"), unit, NewConfig("polygon", "blue", 0), tt.cc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, line := range strings.Split(b.String(), "\n") {
+				if strings.HasPrefix(line, "<polygon ") {
+					attrs, _, _ := strings.Cut(strings.TrimPrefix(line, "<polygon "), " xc=")
+					got = append(got, attrs)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
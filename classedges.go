@@ -0,0 +1,79 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// edgekey identifies a polygon edge by its endpoints, rounded to 1e-7
+// degrees and in either direction
+type edgekey struct{ a, b [2]int64 }
+
+func newedgekey(p, q shp.Point) edgekey {
+	a := [2]int64{int64(math.Round(p.X * 1e7)), int64(math.Round(p.Y * 1e7))}
+	b := [2]int64{int64(math.Round(q.X * 1e7)), int64(math.Round(q.Y * 1e7))}
+	if b[0] < a[0] || (b[0] == a[0] && b[1] < a[1]) {
+		a, b = b, a
+	}
+	return edgekey{a, b}
+}
+
+// classedge is an edge with the class of the first feature seen along it
+type classedge struct {
+	p, q     shp.Point
+	class    int
+	boundary bool
+}
+
+// classboundaries returns the polygon edges shared by features of different
+// classes, in the order first seen. Adjacency is approximated by shared
+// vertices: two features are neighbors along an edge only when both have
+// that edge, with the same endpoints, so it needs topologically clean data;
+// edges that merely overlap, or whose endpoints differ, are not found.
+func classboundaries(recs []record, classof func(row int) int) [][2]shp.Point {
+	edges := map[edgekey]*classedge{}
+	var order []*classedge
+	for _, rec := range recs {
+		poly, ok := rec.shape.(*shp.Polygon)
+		if !ok {
+			continue
+		}
+		class := classof(rec.row)
+		for _, part := range partpoints(poly.Points, poly.Parts, poly.NumPoints) {
+			for i := 1; i < len(part); i++ {
+				k := newedgekey(part[i-1], part[i])
+				if k.a == k.b {
+					continue
+				}
+				e, ok := edges[k]
+				if !ok {
+					e = &classedge{p: part[i-1], q: part[i], class: class}
+					edges[k] = e
+					order = append(order, e)
+				} else if e.class != class {
+					e.boundary = true
+				}
+			}
+		}
+	}
+	var out [][2]shp.Point
+	for _, e := range order {
+		if e.boundary {
+			out = append(out, [2]shp.Point{e.p, e.q})
+		}
+	}
+	return out
+}
+
+// renderclassboundaries draws the edges between classes in the stroke
+func renderclassboundaries(dest io.Writer, recs []record, classof func(row int) int, g Geometry, s Stroke, c Config) {
+	c.maptype, c.color, c.shapesize = "line", s.Color, s.Width
+	if c.shapesize == 0 {
+		c.shapesize = 0.1
+	}
+	for _, e := range classboundaries(recs, classof) {
+		mapparts(dest, e[:], []int32{0}, 2, g, c, false)
+	}
+}
@@ -0,0 +1,120 @@
+package shpdeck
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// ClassMethod selects how Classify finds class breaks
+type ClassMethod int
+
+const (
+	// EqualInterval divides the range of values into equal steps
+	EqualInterval ClassMethod = iota
+	// Quantile puts the same number of values in each class
+	Quantile
+	// Jenks finds natural breaks that minimize the variance within classes
+	Jenks
+)
+
+// Classify divides values into k classes and returns the k+1 class bounds,
+// from the minimum to the maximum value. Class i holds values from
+// breaks[i] up to and including breaks[i+1] (ClassIndex finds it).
+// Jenks makes no more classes than there are distinct values: for data with
+// d < k of them it returns d+1 bounds.
+func Classify(values []float64, method ClassMethod, k int) []float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	data := slices.Clone(values)
+	sort.Float64s(data)
+	n := len(data)
+	k = max(k, 1)
+	breaks := make([]float64, k+1)
+	breaks[0], breaks[k] = data[0], data[n-1]
+	switch method {
+	case Quantile:
+		for i := 1; i < k; i++ {
+			breaks[i] = percentile(data, float64(i)/float64(k))
+		}
+	case Jenks:
+		// there are no more classes than distinct values
+		return jenks(data, min(k, len(slices.Compact(slices.Clone(data)))))
+	default:
+		step := (data[n-1] - data[0]) / float64(k)
+		for i := 1; i < k; i++ {
+			breaks[i] = data[0] + step*float64(i)
+		}
+	}
+	return breaks
+}
+
+// jenks computes natural breaks of sorted data by dynamic programming (Jenks-Fisher)
+func jenks(data []float64, k int) []float64 {
+	n := len(data)
+	lower := make([][]int, n+1)
+	variance := make([][]float64, n+1)
+	for i := range lower {
+		lower[i] = make([]int, k+1)
+		variance[i] = make([]float64, k+1)
+	}
+	for j := 1; j <= k; j++ {
+		lower[1][j] = 1
+		for i := 2; i <= n; i++ {
+			variance[i][j] = math.Inf(1)
+		}
+	}
+	for l := 2; l <= n; l++ {
+		var sum, sumsq, w, v float64
+		for m := 1; m <= l; m++ {
+			lo := l - m + 1
+			val := data[lo-1]
+			sum += val
+			sumsq += val * val
+			w++
+			v = sumsq - sum*sum/w
+			if prev := lo - 1; prev != 0 {
+				for j := 2; j <= k; j++ {
+					if variance[l][j] >= v+variance[prev][j-1] {
+						lower[l][j] = lo
+						variance[l][j] = v + variance[prev][j-1]
+					}
+				}
+			}
+		}
+		lower[l][1] = 1
+		variance[l][1] = v
+	}
+	breaks := make([]float64, k+1)
+	breaks[0], breaks[k] = data[0], data[n-1]
+	for j, end := k, n; j >= 2; j-- {
+		id := lower[end][j] - 2
+		breaks[j-1] = data[id]
+		end = lower[end][j] - 1
+	}
+	return breaks
+}
+
+// ClassIndex returns the class of v given the bounds from Classify,
+// clamping values outside the bounds to the first or last class
+func ClassIndex(v float64, breaks []float64) int {
+	classes := len(breaks) - 1
+	if classes < 1 {
+		return 0
+	}
+	for i := 1; i < classes; i++ {
+		if v <= breaks[i] {
+			return i - 1
+		}
+	}
+	return classes - 1
+}
+
+// percentile interpolates the value at fraction p (0-1) of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	pos := clamp(p, 0, 1) * float64(n-1)
+	lo := int(pos)
+	return sorted[lo] + (sorted[min(lo+1, n-1)]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,66 @@
+package shpdeck
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		method ClassMethod
+		k      int
+		want   []float64
+	}{
+		{"equal interval", []float64{0, 3, 7, 10}, EqualInterval, 2, []float64{0, 5, 10}},
+		{"equal interval unsorted", []float64{10, 0, 4}, EqualInterval, 4, []float64{0, 2.5, 5, 7.5, 10}},
+		{"quantile", []float64{1, 2, 3, 4, 5}, Quantile, 2, []float64{1, 3, 5}},
+		{"quantile interpolated", []float64{1, 2, 3, 4}, Quantile, 2, []float64{1, 2.5, 4}},
+		{"jenks clusters", []float64{1, 2, 3, 10, 11, 12, 20, 21, 22}, Jenks, 3, []float64{1, 3, 12, 22}},
+		{"jenks two groups", []float64{4, 5, 9, 10, 1, 2}, Jenks, 2, []float64{1, 5, 10}},
+		{"jenks constant", []float64{1, 1, 1, 1}, Jenks, 3, []float64{1, 1}},
+		{"jenks two values", []float64{7, 7, 7, 9, 9, 9}, Jenks, 4, []float64{7, 7, 9}},
+		{"jenks as many classes as values", []float64{3, 1, 2}, Jenks, 3, []float64{1, 1, 2, 3}},
+		{"jenks more classes than values", []float64{3, 1, 2}, Jenks, 5, []float64{1, 1, 2, 3}},
+		{"empty", nil, Quantile, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.values, tt.method, tt.k); !slices.Equal(got, tt.want) {
+				t.Errorf("Classify(%v, %v, %d) = %v, want %v", tt.values, tt.method, tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJenksRepeatedValues checks that integer-coded data, with many repeats,
+// gives ascending breaks for every k without panicking
+func TestJenksRepeatedValues(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		values := make([]float64, 1+rng.IntN(12))
+		for i := range values {
+			values[i] = float64(rng.IntN(4))
+		}
+		for k := 1; k <= 6; k++ {
+			breaks := Classify(values, Jenks, k)
+			if !slices.IsSorted(breaks) || breaks[0] != slices.Min(values) || breaks[len(breaks)-1] != slices.Max(values) {
+				t.Fatalf("Classify(%v, Jenks, %d) = %v", values, k, breaks)
+			}
+		}
+	}
+}
+
+func TestClassIndex(t *testing.T) {
+	breaks := []float64{0, 10, 20, 30}
+	for _, tt := range []struct {
+		v    float64
+		want int
+	}{{-5, 0}, {0, 0}, {10, 0}, {10.5, 1}, {20, 1}, {25, 2}, {99, 2}} {
+		if got := ClassIndex(tt.v, breaks); got != tt.want {
+			t.Errorf("ClassIndex(%v) = %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package shpdeck
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// ClipPolygonFromWKT reads the outer ring of a WKT POLYGON, for example
+// "POLYGON ((-80 25, -80 31, -87 31, -87 25, -80 25))", for use as Config.ClipPolygon.
+// Interior rings are ignored, and the closing point is dropped. Z and M
+// values are ignored too. An empty polygon, or an outer ring that is not
+// convex, which ClipPolygon cannot clip to (see Triangulate), is an error.
+func ClipPolygonFromWKT(wkt string) ([]shp.Point, error) {
+	toks := wkttokens(wkt)
+	if len(toks) == 0 || !strings.EqualFold(toks[0], "POLYGON") {
+		return nil, fmt.Errorf("clip: not a WKT POLYGON: %q", wkt)
+	}
+	toks = toks[1:]
+	if len(toks) > 0 && (strings.EqualFold(toks[0], "Z") || strings.EqualFold(toks[0], "M") || strings.EqualFold(toks[0], "ZM")) {
+		toks = toks[1:]
+	}
+	if len(toks) == 1 && strings.EqualFold(toks[0], "EMPTY") {
+		return nil, fmt.Errorf("clip: the WKT POLYGON is empty")
+	}
+	rings, err := wktrings(toks)
+	if err != nil {
+		return nil, fmt.Errorf("clip: malformed WKT POLYGON %q: %v", wkt, err)
+	}
+	pts := rings[0]
+	if n := len(pts); n > 1 && pts[0] == pts[n-1] {
+		pts = pts[:n-1]
+	}
+	if len(pts) < 3 {
+		return nil, fmt.Errorf("clip: polygon needs at least 3 points, got %d", len(pts))
+	}
+	if !convex(pts) {
+		return nil, fmt.Errorf("clip: polygon is not convex")
+	}
+	return pts, nil
+}
+
+// wkttokens splits WKT into parentheses, commas and words
+func wkttokens(s string) []string {
+	var toks []string
+	word := -1
+	for i, r := range s {
+		delim := r == '(' || r == ')' || r == ','
+		if delim || unicode.IsSpace(r) {
+			if word >= 0 {
+				toks = append(toks, s[word:i])
+				word = -1
+			}
+			if delim {
+				toks = append(toks, string(r))
+			}
+		} else if word < 0 {
+			word = i
+		}
+	}
+	if word >= 0 {
+		toks = append(toks, s[word:])
+	}
+	return toks
+}
+
+// wktrings parses the tokens of a list of rings, "((x y, ...), (...))",
+// which must be all that is left
+func wktrings(toks []string) ([][]shp.Point, error) {
+	i := 0
+	expect := func(t string) error {
+		if i >= len(toks) {
+			return fmt.Errorf("want %q at the end", t)
+		}
+		if toks[i] != t {
+			return fmt.Errorf("want %q, not %q", t, toks[i])
+		}
+		i++
+		return nil
+	}
+	if err := expect("("); err != nil {
+		return nil, err
+	}
+	var rings [][]shp.Point
+	for {
+		if err := expect("("); err != nil {
+			return nil, err
+		}
+		var ring []shp.Point
+		for {
+			var coord []float64
+			for i < len(toks) && toks[i] != "," && toks[i] != ")" && toks[i] != "(" {
+				v, err := strconv.ParseFloat(toks[i], 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad coordinate %q", toks[i])
+				}
+				coord = append(coord, v)
+				i++
+			}
+			if len(coord) < 2 || len(coord) > 4 {
+				return nil, fmt.Errorf("a coordinate has %d values", len(coord))
+			}
+			ring = append(ring, shp.Point{X: coord[0], Y: coord[1]})
+			if i < len(toks) && toks[i] == "," {
+				i++
+				continue
+			}
+			break
+		}
+		if err := expect(")"); err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+		if i < len(toks) && toks[i] == "," {
+			i++
+			continue
+		}
+		break
+	}
+	if err := expect(")"); err != nil {
+		return nil, err
+	}
+	if i < len(toks) {
+		return nil, fmt.Errorf("%q after the rings", toks[i])
+	}
+	return rings, nil
+}
+
+// convex reports whether a ring turns the same way at every vertex, and
+// goes around once; rings with no area are not convex
+func convex(ring []shp.Point) bool {
+	n := len(ring)
+	var sign, turn float64
+	for i := range n {
+		a, b, c := ring[i], ring[(i+1)%n], ring[(i+2)%n]
+		cross := side(a, b, c)
+		if cross != 0 {
+			if sign != 0 && (cross > 0) != (sign > 0) {
+				return false
+			}
+			sign = cross
+		}
+		dot := (b.X-a.X)*(c.X-b.X) + (b.Y-a.Y)*(c.Y-b.Y)
+		turn += math.Atan2(cross, dot)
+	}
+	return sign != 0 && math.Abs(math.Abs(turn)-2*math.Pi) < 1e-6
+}
+
+// signedArea is the shoelace area of a ring, positive when counter-clockwise
+func signedArea(ring []shp.Point) float64 {
+	a := 0.0
+	n := len(ring)
+	for i := range n {
+		p, q := ring[i], ring[(i+1)%n]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
+
+// side reports which side of the directed edge a->b the point p is on
+func side(a, b, p shp.Point) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// intersect returns the intersection of segment p-q with the line through a-b
+func intersect(p, q, a, b shp.Point) shp.Point {
+	sp, sq := side(a, b, p), side(a, b, q)
+	t := sp / (sp - sq)
+	return shp.Point{X: p.X + (q.X-p.X)*t, Y: p.Y + (q.Y-p.Y)*t}
+}
+
+// appendpt appends a point unless it repeats the previous one
+func appendpt(pts []shp.Point, p shp.Point) []shp.Point {
+	if n := len(pts); n > 0 && pts[n-1] == p {
+		return pts
+	}
+	return append(pts, p)
+}
+
+// ClipPolygon clips a ring to a convex clip polygon using Sutherland-Hodgman.
+// The clip polygon may be wound either way, but it must be convex:
+// a concave clip polygon gives incorrect results.
+func ClipPolygon(ring, clip []shp.Point) []shp.Point {
+	dir := 1.0
+	if signedArea(clip) < 0 {
+		dir = -1
+	}
+	out := ring
+	for i := range clip {
+		a, b := clip[i], clip[(i+1)%len(clip)]
+		in := out
+		out = nil
+		for j := range in {
+			p, q := in[j], in[(j+1)%len(in)]
+			pin, qin := dir*side(a, b, p) >= 0, dir*side(a, b, q) >= 0
+			switch {
+			case pin && qin:
+				out = appendpt(out, q)
+			case pin && !qin:
+				out = appendpt(out, intersect(p, q, a, b))
+			case !pin && qin:
+				out = appendpt(appendpt(out, intersect(p, q, a, b)), q)
+			}
+		}
+		if len(out) == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// ClipPolyline clips an open line to a convex clip polygon (Cyrus-Beck),
+// returning the pieces that remain inside.
+func ClipPolyline(line, clip []shp.Point) [][]shp.Point {
+	dir := 1.0
+	if signedArea(clip) < 0 {
+		dir = -1
+	}
+	var pieces [][]shp.Point
+	var cur []shp.Point
+	for i := 0; i < len(line)-1; i++ {
+		p, q := line[i], line[i+1]
+		t0, t1 := 0.0, 1.0
+		for j := range clip {
+			a, b := clip[j], clip[(j+1)%len(clip)]
+			sp, sq := dir*side(a, b, p), dir*side(a, b, q)
+			if sp < 0 && sq < 0 {
+				t0, t1 = 1, 0
+				break
+			}
+			if sp < 0 {
+				t0 = max(t0, sp/(sp-sq))
+			} else if sq < 0 {
+				t1 = min(t1, sp/(sp-sq))
+			}
+		}
+		if t0 > t1 {
+			if len(cur) > 1 {
+				pieces = append(pieces, cur)
+			}
+			cur = nil
+			continue
+		}
+		a := shp.Point{X: p.X + (q.X-p.X)*t0, Y: p.Y + (q.Y-p.Y)*t0}
+		b := shp.Point{X: p.X + (q.X-p.X)*t1, Y: p.Y + (q.Y-p.Y)*t1}
+		if len(cur) == 0 || t0 > 0 {
+			if len(cur) > 1 {
+				pieces = append(pieces, cur)
+			}
+			cur = []shp.Point{a}
+		}
+		cur = append(cur, b)
+		if t1 < 1 {
+			pieces = append(pieces, cur)
+			cur = nil
+		}
+	}
+	if len(cur) > 1 {
+		pieces = append(pieces, cur)
+	}
+	return pieces
+}
+
+// inside reports whether a point is inside (or on the edge of) a convex polygon
+func inside(p shp.Point, clip []shp.Point) bool {
+	dir := 1.0
+	if signedArea(clip) < 0 {
+		dir = -1
+	}
+	for i := range clip {
+		if dir*side(clip[i], clip[(i+1)%len(clip)], p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// clipregions returns the convex regions output is clipped to, if any
+func (c Config) clipregions() [][]shp.Point {
+	if len(c.ClipPolygon) < 3 {
+		return c.ClipMask
+	}
+	return append([][]shp.Point{c.ClipPolygon}, c.ClipMask...)
+}
+
+// inclip reports whether a point survives clipping
+func (c Config) inclip(p shp.Point) bool {
+	regions := c.clipregions()
+	if len(regions) == 0 {
+		return true
+	}
+	for _, region := range regions {
+		if inside(p, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// screenbox is the mapped screen rectangle as a ring
+func (c Config) screenbox(g Geometry) []shp.Point {
+	x0, x1, y0, y1 := g.Xmin, g.Xmax, g.Ymin, g.Ymax
+	if c.Relative {
+		x0, x1, y0, y1 = 0, 100, 0, 100
+	}
+	return []shp.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+}
+
+// onscreen reports whether a mapped point survives Config.ClipToScreen
+func (c Config) onscreen(x, y float64, g Geometry) bool {
+	return !c.ClipToScreen || inside(shp.Point{X: x, Y: y}, c.screenbox(g))
+}
+
+// screenclip clips mapped coordinates to the screen box, as polygons when
+// the Config shape is a polygon and as lines otherwise, and returns the
+// x and y coordinates of each piece
+func (c Config) screenclip(x, y []float64, g Geometry) [][2][]float64 {
+	pts := make([]shp.Point, len(x))
+	for i := range x {
+		pts[i] = shp.Point{X: x[i], Y: y[i]}
+	}
+	box := c.screenbox(g)
+	var pieces [][]shp.Point
+	if ispolygon(c.maptype) {
+		if clipped := ClipPolygon(pts, box); len(clipped) > 2 {
+			pieces = append(pieces, clipped)
+		}
+	} else {
+		pieces = ClipPolyline(pts, box)
+	}
+	out := make([][2][]float64, len(pieces))
+	for i, p := range pieces {
+		out[i] = xyslices(p)
+	}
+	return out
+}
+
+// xyslices splits points into their x and y coordinates
+func xyslices(pts []shp.Point) [2][]float64 {
+	xy := [2][]float64{make([]float64, len(pts)), make([]float64, len(pts))}
+	for i, p := range pts {
+		xy[0][i], xy[1][i] = p.X, p.Y
+	}
+	return xy
+}
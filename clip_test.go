@@ -0,0 +1,52 @@
+package shpdeck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestClipPolygonFromWKT(t *testing.T) {
+	florida := []shp.Point{{X: -80, Y: 25}, {X: -80, Y: 31}, {X: -87, Y: 31}, {X: -87, Y: 25}}
+	tests := []struct {
+		name string
+		wkt  string
+		want []shp.Point
+		err  string
+	}{
+		{"closed", "POLYGON ((-80 25, -80 31, -87 31, -87 25, -80 25))", florida, ""},
+		{"spaced", " polygon ( ( -80 25 , -80 31,-87 31, -87 25 ) ) ", florida, ""},
+		{"z values", "POLYGON Z ((-80 25 1, -80 31 2, -87 31 3, -87 25 4, -80 25 1))", florida, ""},
+		{"hole ignored", "POLYGON ((-80 25, -80 31, -87 31, -87 25, -80 25), (-82 27, -84 27, -84 29, -82 27))", florida, ""},
+		{"empty", "POLYGON EMPTY", nil, "empty"},
+		{"concave", "POLYGON ((0 0, 4 0, 4 4, 2 1, 0 4, 0 0))", nil, "not convex"},
+		{"self-crossing", "POLYGON ((0 0, 4 4, 4 0, 0 4, 0 0))", nil, "not convex"},
+		{"pentagram", "POLYGON ((0 3, 2 -3, -3 1, 3 1, -2 -3, 0 3))", nil, "not convex"},
+		{"collinear", "POLYGON ((0 0, 1 1, 2 2, 0 0))", nil, "not convex"},
+		{"two points", "POLYGON ((0 0, 1 1, 0 0))", nil, "at least 3 points"},
+		{"not a polygon", "LINESTRING (0 0, 1 1)", nil, "not a WKT POLYGON"},
+		{"unclosed parenthesis", "POLYGON ((0 0, 4 0, 4 4, 0 0)", nil, "malformed"},
+		{"trailing text", "POLYGON ((0 0, 4 0, 4 4, 0 0)) extra", nil, "malformed"},
+		{"bad number", "POLYGON ((0 0, 4 x, 4 4, 0 0))", nil, "bad coordinate"},
+		{"one value", "POLYGON ((0 0, 4, 4 4, 0 0))", nil, "1 values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipPolygonFromWKT(tt.wkt)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error %v, want one about %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ring %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
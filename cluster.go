@@ -0,0 +1,72 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Cluster is a group of nearby points, in screen coordinates
+type Cluster struct {
+	X, Y  float64 // mean position of the members
+	Count int
+}
+
+// ClusterPoints groups the points of mp that lie within radius (in screen
+// units, after mapping with g) of a cluster's first point. Points are taken in
+// file order and join the earliest cluster in reach, so the result is deterministic.
+func ClusterPoints(mp *shp.MultiPoint, g Geometry, radius float64) []Cluster {
+	var c Config
+	var clusters []Cluster
+	var seeds [][2]float64
+	grid := map[[2]int][]int{} // cluster indices by the grid cell of their seed
+	cell := func(x, y float64) [2]int {
+		return [2]int{int(math.Floor(x / radius)), int(math.Floor(y / radius))}
+	}
+	for _, p := range mp.Points {
+		x, y := c.mappoint(p, g)
+		best := -1
+		if radius > 0 {
+			k := cell(x, y)
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					for _, i := range grid[[2]int{k[0] + dx, k[1] + dy}] {
+						if (best < 0 || i < best) && math.Hypot(seeds[i][0]-x, seeds[i][1]-y) <= radius {
+							best = i
+						}
+					}
+				}
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, Cluster{X: x, Y: y, Count: 1})
+			seeds = append(seeds, [2]float64{x, y})
+			if radius > 0 {
+				k := cell(x, y)
+				grid[k] = append(grid[k], len(clusters)-1)
+			}
+			continue
+		}
+		cl := &clusters[best]
+		cl.Count++
+		cl.X += (x - cl.X) / float64(cl.Count)
+		cl.Y += (y - cl.Y) / float64(cl.Count)
+	}
+	return clusters
+}
+
+// RenderClusters draws each cluster as a dot whose area grows with its count,
+// starting from the Config size for a single point, labeled with the count
+func RenderClusters(dest io.Writer, clusters []Cluster, c Config) {
+	fill, op := colorattr(c.color)
+	for _, cl := range clusters {
+		size := c.shapesize * math.Sqrt(float64(cl.Count))
+		c.dot(dest, cl.X, cl.Y, fill, op, size)
+		if cl.Count > 1 {
+			ts := c.textstyle(TextStyle{Size: size / 3, Color: "white", Align: "center"})
+			c.text(dest, ts, cl.X, cl.Y-ts.Size/3, strconv.Itoa(cl.Count), 0)
+		}
+	}
+}
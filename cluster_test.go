@@ -0,0 +1,37 @@
+package shpdeck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestClusterPoints joins each point to the earliest cluster whose first
+// point is in reach, across grid cells, and keeps every point with no radius
+func TestClusterPoints(t *testing.T) {
+	pts := []shp.Point{{X: 1, Y: 1}, {X: 1.2, Y: 1}, {X: 5, Y: 5}, {X: 1.1, Y: 1.3}, {X: 0.6, Y: 0.6}, {X: 4.6, Y: 5}, {X: 9, Y: 9}}
+	mp := &shp.MultiPoint{Points: pts, NumPoints: int32(len(pts))}
+	tests := []struct {
+		name   string
+		radius float64
+		want   []Cluster
+	}{
+		{"no radius", 0, []Cluster{{10, 10, 1}, {12, 10, 1}, {50, 50, 1}, {11, 13, 1}, {6, 6, 1}, {46, 50, 1}, {90, 90, 1}}},
+		{"radius", 6, []Cluster{{9.75, 9.75, 4}, {48, 50, 2}, {90, 90, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClusterPoints(mp, unit, tt.radius)
+			if len(got) != len(tt.want) {
+				t.Fatalf("clusters %v, want %v", got, tt.want)
+			}
+			for i, cl := range got {
+				w := tt.want[i]
+				if cl.Count != w.Count || math.Abs(cl.X-w.X) > 1e-9 || math.Abs(cl.Y-w.Y) > 1e-9 {
+					t.Errorf("cluster %d is %v, want %v", i, cl, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package shpdeck
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestNamedColor(t *testing.T) {
+	tests := []struct {
+		name string
+		want color.RGBA
+		ok   bool
+	}{
+		{"maroon", color.RGBA{0x80, 0x00, 0x00, 0xff}, true},
+		{"steelblue", color.RGBA{0x46, 0x82, 0xb4, 0xff}, true},
+		{"rebeccapurple", color.RGBA{0x66, 0x33, 0x99, 0xff}, true},
+		{" SteelBlue ", color.RGBA{0x46, 0x82, 0xb4, 0xff}, true},
+		{"#f80", color.RGBA{0xff, 0x88, 0x00, 0xff}, true},
+		{"#4682b4", color.RGBA{0x46, 0x82, 0xb4, 0xff}, true},
+		{"no such color", color.RGBA{}, false},
+		{"#12345", color.RGBA{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, ok := NamedColor(tt.name); c != tt.want || ok != tt.ok {
+				t.Errorf("NamedColor = %v, %v, want %v, %v", c, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
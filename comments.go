@@ -0,0 +1,71 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// attributes finds the reader holding the DBF attributes of a records source
+func attributes(r records) *shp.Reader {
+	switch v := r.(type) {
+	case *shp.Reader:
+		return v
+	case *recordBuffer:
+		return v.reader
+	case *maskrecords:
+		return attributes(v.records)
+	case *tolerantReader:
+		return v.attrs
+	}
+	return nil
+}
+
+// featurecomments writes selected attributes of each feature as a comment
+type featurecomments struct {
+	r      *shp.Reader
+	enc    string // Config.Encoding
+	names  []string
+	fields []int
+}
+
+// newfeaturecomments resolves the field names; names not in r are left out
+func newfeaturecomments(r *shp.Reader, names []string, enc string) *featurecomments {
+	fc := &featurecomments{r: r, enc: enc}
+	if r == nil {
+		return fc
+	}
+	for _, name := range names {
+		if fi := fieldIndex(r, name); fi >= 0 {
+			fc.names = append(fc.names, name)
+			fc.fields = append(fc.fields, fi)
+		}
+	}
+	return fc
+}
+
+// commentesc keeps a value from ending or breaking the comment it is in:
+// it splits every run of dashes, so that no "--" is left, and escapes quotes
+func commentesc(s string) string {
+	s = strings.ReplaceAll(s, `"`, "&quot;")
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "- -")
+	}
+	return s
+}
+
+// write writes the comment for record n
+func (fc *featurecomments) write(w io.Writer, n int) {
+	if len(fc.fields) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- record %d", n)
+	for i, fi := range fc.fields {
+		fmt.Fprintf(&b, " %s=\"%s\"", commentesc(fc.names[i]), commentesc(DecodeText(fc.r.ReadAttribute(n, fi), fc.enc)))
+	}
+	b.WriteString(" -->\n")
+	io.WriteString(w, b.String())
+}
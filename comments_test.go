@@ -0,0 +1,22 @@
+package shpdeck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentEscape(t *testing.T) {
+	for _, s := range []string{"a--b", "a---b", "----", `say "hi"`, "-", "a-b"} {
+		got := commentesc(s)
+		if strings.Contains(got, "--") || strings.Contains(got, `"`) {
+			t.Errorf("commentesc(%q) = %q", s, got)
+		}
+	}
+	vw := NewValidatingWriter(new(strings.Builder))
+	if _, err := vw.Write([]byte("<!-- record 0 name=\"" + commentesc("a---b") + "\" -->\n")); err != nil {
+		t.Error(err)
+	}
+	if err := vw.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,93 @@
+package shpdeck
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConvertDir renders every .shp file in inDir to a file of the same name in
+// outDir, with the extension .xml (or .svg for the SVG Format), using up to
+// workers goroutines. If g has no geographic bounds (Longmin == Longmax),
+// each file is mapped from its own bounds (see DataBounds) into g's screen
+// box, centered on the antimeridian when the data crosses it.
+// Each file's result is reported to the Logger, which must then be safe for
+// concurrent use; the errors of all files that failed are returned together.
+func ConvertDir(inDir, outDir string, g Geometry, c Config, workers int) error {
+	files, err := filepath.Glob(filepath.Join(inDir, "*.shp"))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	ext := ".xml"
+	if c.Format == SVG {
+		ext = ".svg"
+	}
+	workers = max(workers, 1)
+	jobs := make(chan int)
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				in := files[i]
+				out := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))+ext)
+				st, err := convertfile(in, out, g, c)
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %w", in, err)
+				}
+				if c.Logger != nil {
+					if err != nil {
+						c.Logger(fmt.Sprintf("%s: %v", in, err))
+					} else {
+						c.Logger(fmt.Sprintf("%s: %d features, %d skipped, %d bytes to %s", in, st.Features, st.Skipped, st.Bytes, out))
+					}
+				}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// convertfile renders one shapefile to a new file
+func convertfile(in, out string, g Geometry, c Config) (Stats, error) {
+	if g.Longmin == g.Longmax {
+		r, err := Open(in)
+		if err != nil {
+			return Stats{}, err
+		}
+		b, err := DataBounds(r)
+		r.Close()
+		if err != nil {
+			return Stats{}, err
+		}
+		g.Longmin, g.Longmax, g.Latmin, g.Latmax = b.Longmin, b.Longmax, b.Latmin, b.Latmax
+		if g.Longmax > 180 && c.CentralMeridian == 0 {
+			// data across the antimeridian: center the map on it
+			mid := (g.Longmin + g.Longmax) / 2
+			c.CentralMeridian = mid
+			g.Longmin, g.Longmax = g.Longmin-mid, g.Longmax-mid
+		}
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return Stats{}, err
+	}
+	st, err := RenderFile(f, in, g, c)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return st, err
+}
@@ -0,0 +1,104 @@
+package shpdeck
+
+import (
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// occupancycells is the size of the Stats.Occupancy grid in each direction
+const occupancycells = 8
+
+// Corner is a corner of the screen box
+type Corner int
+
+const (
+	BottomLeft Corner = iota
+	BottomRight
+	TopLeft
+	TopRight
+)
+
+// occupy counts a rendered shape in every cell of the occupancy grid
+// that its mapped bounding box covers
+func (st *Stats) occupy(s shp.Shape, g Geometry, c Config) {
+	b := s.BBox()
+	corners := []shp.Point{{X: b.MinX, Y: b.MinY}, {X: b.MinX, Y: b.MaxY}, {X: b.MaxX, Y: b.MaxY}, {X: b.MaxX, Y: b.MinY}}
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, p := range corners {
+		x, y := c.mappoint(p, g)
+		x0, x1 = min(x0, x), max(x1, x)
+		y0, y1 = min(y0, y), max(y1, y)
+	}
+	if math.IsNaN(x0 + x1 + y0 + y1) {
+		return
+	}
+	cell := func(v, lo, hi float64) int {
+		if hi == lo {
+			return 0
+		}
+		return int(clamp(math.Floor((v-lo)/(hi-lo)*occupancycells), 0, occupancycells-1))
+	}
+	// cell rows count up from Ymin, whichever way the screen y grows
+	box := c.screenbox(g)
+	i0, i1 := cell(x0, box[0].X, box[2].X), cell(x1, box[0].X, box[2].X)
+	j0, j1 := cell(y0, box[0].Y, box[2].Y), cell(y1, box[0].Y, box[2].Y)
+	for i := min(i0, i1); i <= max(i0, i1); i++ {
+		for j := min(j0, j1); j <= max(j0, j1); j++ {
+			st.Occupancy[j][i]++
+		}
+	}
+}
+
+// LeastBusyCorner returns the corner of the screen box with the fewest
+// rendered features in the quarter of the occupancy grid nearest to it,
+// for placing a legend, or fallback when it is no busier than the rest.
+// Top and bottom are the Ymax and Ymin sides of the screen box.
+func (st Stats) LeastBusyCorner(fallback Corner) Corner {
+	const n = occupancycells / 2
+	count := func(k Corner) int {
+		i0, j0 := 0, 0
+		if k == BottomRight || k == TopRight {
+			i0 = occupancycells - n
+		}
+		if k == TopLeft || k == TopRight {
+			j0 = occupancycells - n
+		}
+		sum := 0
+		for j := j0; j < j0+n; j++ {
+			for i := i0; i < i0+n; i++ {
+				sum += st.Occupancy[j][i]
+			}
+		}
+		return sum
+	}
+	best, fewest := fallback, count(fallback)
+	for _, k := range []Corner{BottomLeft, BottomRight, TopLeft, TopRight} {
+		if v := count(k); v < fewest {
+			best, fewest = k, v
+		}
+	}
+	return best
+}
+
+// CornerOrigin returns the Xmin, Ymin corner of a w by h box placed in a
+// corner of the screen box of g, inset by margin, such as the x, y of a legend
+func (g Geometry) CornerOrigin(k Corner, w, h, margin float64) (float64, float64) {
+	x := min(g.Xmin, g.Xmax) + margin
+	if k == BottomRight || k == TopRight {
+		x = max(g.Xmin, g.Xmax) - margin - w
+	}
+	// toward Ymin is the bottom, whichever way y grows
+	y := g.Ymin + margin
+	if g.Ymax < g.Ymin {
+		y = g.Ymin - margin - h
+	}
+	if k == TopLeft || k == TopRight {
+		y = g.Ymax - margin - h
+		if g.Ymax < g.Ymin {
+			y = g.Ymax + margin
+		}
+	}
+	return x, y
+}
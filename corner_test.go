@@ -0,0 +1,21 @@
+package shpdeck
+
+import "testing"
+
+// TestOccupyRelative buckets shapes against the screen box that they are
+// mapped to, which is 0..100 with Relative whatever the Geometry
+func TestOccupyRelative(t *testing.T) {
+	g := Geometry{Xmin: 200, Xmax: 600, Ymin: 200, Ymax: 600, Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+	for _, relative := range []bool{false, true} {
+		c := NewConfig("polygon", "red", 0)
+		c.Relative = relative
+		var st Stats
+		st.occupy(square(9, 9, 0.5), g, c)
+		if st.Occupancy[occupancycells-1][occupancycells-1] != 1 {
+			t.Errorf("relative %v: the top right shape is not in the top right cell: %v", relative, st.Occupancy)
+		}
+		if k := st.LeastBusyCorner(TopRight); k != BottomLeft {
+			t.Errorf("relative %v: least busy corner %v, want %v", relative, k, BottomLeft)
+		}
+	}
+}
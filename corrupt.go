@@ -0,0 +1,250 @@
+package shpdeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// records is the part of *shp.Reader the render loop uses
+type records interface {
+	Next() bool
+	Shape() (int, shp.Shape)
+	Err() error
+}
+
+// tolerantReader reads a .shp file record by record, using each record's
+// length to step over records that cannot be parsed instead of stopping.
+// It decodes every shape type of the specification; the DBF attributes are
+// read through a *shp.Reader when the file has one.
+type tolerantReader struct {
+	f      io.ReadSeeker
+	attrs  *shp.Reader // the DBF attributes, or nil
+	index  int
+	shape  shp.Shape
+	failed []int
+	logger func(string)
+	err    error
+}
+
+// newTolerantReader positions a reader after the 100 byte .shp header
+func newTolerantReader(f io.ReadSeeker) (*tolerantReader, error) {
+	if _, err := f.Seek(100, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &tolerantReader{f: f, index: -1}, nil
+}
+
+func (t *tolerantReader) Shape() (int, shp.Shape) { return t.index, t.shape }
+func (t *tolerantReader) Err() error              { return t.err }
+
+// Next advances to the next readable record, recording any it steps over
+func (t *tolerantReader) Next() bool {
+	for t.err == nil {
+		var header [8]byte
+		if _, err := io.ReadFull(t.f, header[:]); err != nil {
+			if err != io.EOF {
+				t.err = fmt.Errorf("record %d header: %v", t.index+1, err)
+			}
+			return false
+		}
+		t.index++
+		size := int64(binary.BigEndian.Uint32(header[4:])) * 2
+		if size < 4 || size > math.MaxInt32 {
+			t.err = fmt.Errorf("record %d: bad content length %d, cannot continue", t.index, size)
+			return false
+		}
+		content := make([]byte, size)
+		if _, err := io.ReadFull(t.f, content); err != nil {
+			t.fail(fmt.Sprintf("truncated: %v", err))
+			return false
+		}
+		s, err := parseshape(content)
+		if err != nil {
+			t.fail(err.Error())
+			continue
+		}
+		t.shape = s
+		return true
+	}
+	return false
+}
+
+// fail records a record that could not be parsed
+func (t *tolerantReader) fail(reason string) {
+	t.failed = append(t.failed, t.index)
+	if t.logger != nil {
+		t.logger(fmt.Sprintf("record %d: skipped: corrupt: %s", t.index, reason))
+	}
+}
+
+// parseshape decodes the content of one .shp record
+func parseshape(b []byte) (shp.Shape, error) {
+	r := bytes.NewReader(b)
+	var st int32
+	binary.Read(r, binary.LittleEndian, &st)
+	switch t := shp.ShapeType(st); t {
+	case shp.NULL:
+		return &shp.Null{}, nil
+	case shp.POINT:
+		var p shp.Point
+		if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+			return nil, fmt.Errorf("point: %v", err)
+		}
+		return &p, nil
+	case shp.POINTZ:
+		var xyz [3]float64
+		if err := binary.Read(r, binary.LittleEndian, &xyz); err != nil {
+			return nil, fmt.Errorf("point: %v", err)
+		}
+		p := shp.PointZ{X: xyz[0], Y: xyz[1], Z: xyz[2]}
+		binary.Read(r, binary.LittleEndian, &p.M) // the measure is optional
+		return &p, nil
+	case shp.POINTM:
+		var p shp.PointM
+		if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+			return nil, fmt.Errorf("point: %v", err)
+		}
+		return &p, nil
+	case shp.MULTIPOINT, shp.MULTIPOINTZ, shp.MULTIPOINTM:
+		var mp shp.MultiPoint
+		binary.Read(r, binary.LittleEndian, &mp.Box)
+		if err := binary.Read(r, binary.LittleEndian, &mp.NumPoints); err != nil {
+			return nil, fmt.Errorf("multipoint: %v", err)
+		}
+		if mp.NumPoints < 0 || int(mp.NumPoints)*16 > r.Len() {
+			return nil, fmt.Errorf("multipoint: %d points do not fit the record", mp.NumPoints)
+		}
+		mp.Points = make([]shp.Point, mp.NumPoints)
+		binary.Read(r, binary.LittleEndian, mp.Points)
+		switch t {
+		case shp.MULTIPOINTZ:
+			z, m := measures(r, mp.NumPoints, true)
+			return &shp.MultiPointZ{Box: mp.Box, NumPoints: mp.NumPoints, Points: mp.Points,
+				ZRange: z.bounds, ZArray: z.values, MRange: m.bounds, MArray: m.values}, nil
+		case shp.MULTIPOINTM:
+			_, m := measures(r, mp.NumPoints, false)
+			return &shp.MultiPointM{Box: mp.Box, NumPoints: mp.NumPoints, Points: mp.Points,
+				MRange: m.bounds, MArray: m.values}, nil
+		}
+		return &mp, nil
+	case shp.POLYLINE, shp.POLYGON, shp.POLYLINEZ, shp.POLYGONZ, shp.POLYLINEM, shp.POLYGONM, shp.MULTIPATCH:
+		var pl shp.PolyLine
+		binary.Read(r, binary.LittleEndian, &pl.Box)
+		binary.Read(r, binary.LittleEndian, &pl.NumParts)
+		if err := binary.Read(r, binary.LittleEndian, &pl.NumPoints); err != nil {
+			return nil, fmt.Errorf("shape header: %v", err)
+		}
+		typesize := 0
+		if t == shp.MULTIPATCH {
+			typesize = 4
+		}
+		if pl.NumParts < 0 || pl.NumPoints < 0 || int(pl.NumParts)*(4+typesize)+int(pl.NumPoints)*16 > r.Len() {
+			return nil, fmt.Errorf("%d parts and %d points do not fit the record", pl.NumParts, pl.NumPoints)
+		}
+		pl.Parts = make([]int32, pl.NumParts)
+		binary.Read(r, binary.LittleEndian, pl.Parts)
+		var parttypes []int32
+		if t == shp.MULTIPATCH {
+			parttypes = make([]int32, pl.NumParts)
+			binary.Read(r, binary.LittleEndian, parttypes)
+		}
+		pl.Points = make([]shp.Point, pl.NumPoints)
+		binary.Read(r, binary.LittleEndian, pl.Points)
+		if t == shp.MULTIPATCH {
+			// the part types cannot follow parts that are repaired
+			for i, p := range pl.Parts {
+				if p < 0 || p > pl.NumPoints || (i > 0 && p < pl.Parts[i-1]) {
+					return nil, fmt.Errorf("part %d starts at bad index %d", i, p)
+				}
+			}
+		} else {
+			pl.Parts = normalizeparts(pl.Parts, pl.NumPoints, Config{})
+			pl.NumParts = int32(len(pl.Parts))
+		}
+		switch t {
+		case shp.POLYGON:
+			poly := shp.Polygon(pl)
+			return &poly, nil
+		case shp.POLYLINEZ, shp.POLYGONZ:
+			z, m := measures(r, pl.NumPoints, true)
+			plz := shp.PolyLineZ{Box: pl.Box, NumParts: pl.NumParts, NumPoints: pl.NumPoints, Parts: pl.Parts, Points: pl.Points,
+				ZRange: z.bounds, ZArray: z.values, MRange: m.bounds, MArray: m.values}
+			if t == shp.POLYGONZ {
+				poly := shp.PolygonZ(plz)
+				return &poly, nil
+			}
+			return &plz, nil
+		case shp.POLYLINEM:
+			_, m := measures(r, pl.NumPoints, false)
+			return &shp.PolyLineM{Box: pl.Box, NumParts: pl.NumParts, NumPoints: pl.NumPoints, Parts: pl.Parts, Points: pl.Points,
+				MRange: m.bounds, MArray: m.values}, nil
+		case shp.POLYGONM:
+			_, m := measures(r, pl.NumPoints, false)
+			return &shp.PolygonM{Box: pl.Box, NumParts: pl.NumParts, NumPoints: pl.NumPoints, Parts: pl.Parts, Points: pl.Points,
+				MRange: m.bounds, MArray: m.values}, nil
+		case shp.MULTIPATCH:
+			z, m := measures(r, pl.NumPoints, true)
+			return &shp.MultiPatch{Box: pl.Box, NumParts: pl.NumParts, NumPoints: pl.NumPoints, Parts: pl.Parts, PartTypes: parttypes,
+				Points: pl.Points, ZRange: z.bounds, ZArray: z.values, MRange: m.bounds, MArray: m.values}, nil
+		}
+		return &pl, nil
+	}
+	return nil, fmt.Errorf("unsupported shape type %d", st)
+}
+
+// measure is the range and values of the Z or M coordinates of a record
+type measure struct {
+	bounds [2]float64
+	values []float64
+}
+
+// measures reads the Z coordinates of n points, if z, and the M values
+// that follow them. Either is left empty if the record ends before it;
+// the specification makes the M values optional.
+func measures(r *bytes.Reader, n int32, z bool) (zs, ms measure) {
+	read := func() (v measure) {
+		if r.Len() < 16+int(n)*8 {
+			return v
+		}
+		v.values = make([]float64, n)
+		binary.Read(r, binary.LittleEndian, &v.bounds)
+		binary.Read(r, binary.LittleEndian, v.values)
+		return v
+	}
+	if z {
+		zs = read()
+	}
+	return zs, read()
+}
+
+// renderTolerant renders a shapefile, skipping records that fail to parse
+func renderTolerant(dest io.Writer, filename string, g Geometry, c Config) (Stats, error) {
+	var st Stats
+	f, err := os.Open(filename)
+	if err != nil {
+		return st, err
+	}
+	defer f.Close()
+	t, err := newTolerantReader(f)
+	if err != nil {
+		return st, err
+	}
+	cw := &countWriter{w: dest}
+	boundscomment(cw, g, c)
+	t.logger = c.Logger
+	// the table is read with go-shp, which only needs the .shp header to be sound
+	if attrs, err := shp.Open(filename); err == nil {
+		defer attrs.Close()
+		t.attrs = attrs
+	}
+	err = renderloop(cw, t, g, c, &st, nil)
+	st.Bytes = cw.n
+	st.Failed = t.failed
+	return st, err
+}
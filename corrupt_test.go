@@ -0,0 +1,103 @@
+package shpdeck
+
+import (
+	"encoding/binary"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestSkipCorruptShapeTypes(t *testing.T) {
+	line := []shp.Point{{X: 1, Y: 1}, {X: 2, Y: 3}, {X: 4, Y: 2}}
+	tests := []struct {
+		name  string
+		st    shp.ShapeType
+		shape shp.Shape
+	}{
+		{"polyline", shp.POLYLINE, shp.NewPolyLine([][]shp.Point{line})},
+		{"point z", shp.POINTZ, &shp.PointZ{X: 1, Y: 2, Z: 3, M: 4}},
+		{"polyline z", shp.POLYLINEZ, &shp.PolyLineZ{NumParts: 1, NumPoints: 3, Parts: []int32{0}, Points: line,
+			ZArray: []float64{1, 2, 3}, MArray: []float64{0, 0, 0}}},
+		{"polygon m", shp.POLYGONM, &shp.PolygonM{NumParts: 1, NumPoints: 3, Parts: []int32{0}, Points: line,
+			MArray: []float64{0, 0, 0}}},
+		{"multipatch", shp.MULTIPATCH, &shp.MultiPatch{NumParts: 1, NumPoints: 3, Parts: []int32{0}, PartTypes: []int32{0}, Points: line,
+			ZArray: []float64{1, 2, 3}, MArray: []float64{0, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeShapefile(t, tt.st, []shp.Shape{tt.shape}, []shp.Field{shp.StringField("NAME", 8)}, [][]any{{"a"}})
+			c := NewConfig("line", "red", 0.2)
+			c.SkipCorrupt = true
+			c.CommentFields = []string{"NAME"}
+			var b strings.Builder
+			st, err := RenderFile(&b, filename, unit, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(st.Failed) > 0 {
+				t.Errorf("records %v reported as corrupt", st.Failed)
+			}
+			if !strings.Contains(b.String(), `NAME="a"`) {
+				t.Errorf("no attribute comment in\n%s", b.String())
+			}
+		})
+	}
+}
+
+// TestSkipCorruptRecord overwrites a field of record 1: a record whose points
+// do not fit is skipped, and one whose parts are bad is repaired and drawn
+func TestSkipCorruptRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		at       int64 // offset in the record content
+		value    uint32
+		failed   []int
+		polygons int
+	}{
+		{"points past the record", 40, 1 << 20, []int{1}, 2},
+		{"part past the points", 44, 99, nil, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := []shp.Shape{square(0, 0, 1), square(2, 2, 1), square(4, 4, 1)}
+			rows := [][]any{{"first"}, {"second"}, {"third"}}
+			filename := writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("NAME", 8)}, rows)
+			shx, err := os.ReadFile(strings.TrimSuffix(filename, ".shp") + ".shx")
+			if err != nil {
+				t.Fatal(err)
+			}
+			offset := int64(binary.BigEndian.Uint32(shx[108:])) * 2
+			f, err := os.OpenFile(filename, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteAt(binary.LittleEndian.AppendUint32(nil, tt.value), offset+8+tt.at); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			c := NewConfig("polygon", "red", 0)
+			c.SkipCorrupt = true
+			c.CommentFields = []string{"NAME"}
+			var b strings.Builder
+			st, err := RenderFile(&b, filename, unit, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(st.Failed, tt.failed) {
+				t.Errorf("failed records %v, want %v", st.Failed, tt.failed)
+			}
+			for _, want := range []string{`NAME="first"`, `NAME="third"`} {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("no %s in\n%s", want, b.String())
+				}
+			}
+			if n := strings.Count(b.String(), "<polygon "); n != tt.polygons {
+				t.Errorf("%d polygons drawn, want %d", n, tt.polygons)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package shpdeck
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// FieldInfo describes one field of a DBF table
+type FieldInfo struct {
+	Name     string
+	Type     FieldType
+	Length   int // width in bytes
+	Decimals int // digits after the decimal point, for numeric fields
+}
+
+// FieldType is the DBF type code of a field
+type FieldType byte
+
+// DBF field types
+const (
+	CharField    FieldType = 'C' // text
+	NumericField FieldType = 'N' // number stored as text, with Decimals digits
+	FloatField   FieldType = 'F' // floating point number stored as text
+	DateField    FieldType = 'D' // date as YYYYMMDD
+	LogicalField FieldType = 'L' // T, F, Y, N or ? for unknown
+	MemoField    FieldType = 'M' // reference to a memo file
+)
+
+// Numeric reports whether values of the field can be read as numbers,
+// for example for a choropleth
+func (t FieldType) Numeric() bool {
+	return t == NumericField || t == FloatField
+}
+
+// String names the field type
+func (t FieldType) String() string {
+	switch t {
+	case CharField:
+		return "char"
+	case NumericField:
+		return "numeric"
+	case FloatField:
+		return "float"
+	case DateField:
+		return "date"
+	case LogicalField:
+		return "logical"
+	case MemoField:
+		return "memo"
+	}
+	return fmt.Sprintf("unknown(%q)", byte(t))
+}
+
+// Fields describes the fields of the shapefile's DBF table
+func Fields(r *shp.Reader) []FieldInfo {
+	fields := r.Fields()
+	info := make([]FieldInfo, len(fields))
+	for i, f := range fields {
+		info[i] = FieldInfo{Name: f.String(), Type: FieldType(f.Fieldtype), Length: int(f.Size), Decimals: int(f.Precision)}
+	}
+	return info
+}
+
+// dbfname returns the name of the DBF file that goes with a shapefile,
+// made as go-shp makes it, by replacing the last three characters
+func dbfname(filename string) string {
+	if len(filename) < 3 {
+		return filename + ".dbf"
+	}
+	return filename[:len(filename)-3] + "dbf"
+}
+
+// DeletedRecords reads the deletion markers of a shapefile's DBF table.
+// deleted[i] is true when record i is flagged deleted ('*').
+func DeletedRecords(filename string) ([]bool, error) {
+	f, err := os.Open(dbfname(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var header struct {
+		Version      byte
+		Updated      [3]byte
+		NumRecords   uint32
+		HeaderLength uint16
+		RecordLength uint16
+	}
+	if err := binary.Read(f, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("dbf header: %v", err)
+	}
+	if header.RecordLength == 0 {
+		return nil, fmt.Errorf("dbf header: records of length 0")
+	}
+	if _, err := f.Seek(int64(header.HeaderLength), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("dbf header: %v", err)
+	}
+	deleted := make([]bool, header.NumRecords)
+	rec := make([]byte, header.RecordLength)
+	br := bufio.NewReader(f)
+	for i := range deleted {
+		if _, err := io.ReadFull(br, rec); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return deleted[:i], nil
+			}
+			return nil, fmt.Errorf("dbf record %d: %v", i, err)
+		}
+		deleted[i] = rec[0] == '*'
+	}
+	return deleted, nil
+}
+
+// readerdeleted reads the deletion markers of the DBF table r reads, which
+// it finds by the file name go-shp keeps unexported in the Reader. Without
+// a table, or a reader, no record is deleted.
+func readerdeleted(r *shp.Reader) []bool {
+	if r == nil {
+		return []bool{}
+	}
+	name := reflect.ValueOf(r).Elem().FieldByName("filename")
+	if name.Kind() != reflect.String {
+		return []bool{}
+	}
+	deleted, err := DeletedRecords(name.String() + "shp") // the name ends in "."
+	if err != nil {
+		return []bool{}
+	}
+	return deleted
+}
+
+// isdeleted reports whether deleted marks the row
+func isdeleted(deleted []bool, row int) bool {
+	return row >= 0 && row < len(deleted) && deleted[row]
+}
@@ -0,0 +1,160 @@
+package shpdeck
+
+import (
+	"encoding/binary"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// markdeleted sets the deletion flag of the given rows of a shapefile's DBF
+func markdeleted(tb testing.TB, filename string, rows ...int) {
+	tb.Helper()
+	f, err := os.OpenFile(dbfname(filename), os.O_RDWR, 0)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	header := make([]byte, 12)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		tb.Fatal(err)
+	}
+	headerlen, recordlen := binary.LittleEndian.Uint16(header[8:]), binary.LittleEndian.Uint16(header[10:])
+	for _, row := range rows {
+		if _, err := f.WriteAt([]byte{'*'}, int64(headerlen)+int64(row)*int64(recordlen)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestDeletedRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted []int
+	}{
+		{"none", nil},
+		{"middle row", []int{1}},
+		{"first and last", []int{0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := []shp.Shape{square(0, 0, 1), square(2, 2, 1), square(4, 4, 1)}
+			rows := [][]any{{"a"}, {"b"}, {"c"}}
+			filename := writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("NAME", 4)}, rows)
+			markdeleted(t, filename, tt.deleted...)
+
+			flags, err := DeletedRecords(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := make([]bool, len(shapes))
+			for _, row := range tt.deleted {
+				want[row] = true
+			}
+			if !slices.Equal(flags, want) {
+				t.Errorf("DeletedRecords = %v, want %v", flags, want)
+			}
+
+			var b strings.Builder
+			st, err := RenderFile(&b, filename, unit, NewConfig("polygon", "red", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Deleted != len(tt.deleted) {
+				t.Errorf("Stats.Deleted = %d, want %d", st.Deleted, len(tt.deleted))
+			}
+			if n := strings.Count(b.String(), "<polygon "); n != len(shapes)-len(tt.deleted) {
+				t.Errorf("%d polygons drawn, want %d", n, len(shapes)-len(tt.deleted))
+			}
+		})
+	}
+}
+
+// TestDeletedEverywhere leaves the deleted middle record out of every
+// render of a reader, and of the values behind its classes and bounds
+func TestDeletedEverywhere(t *testing.T) {
+	shapes := []shp.Shape{square(0, 0, 1), square(2, 2, 1), square(4, 4, 1)}
+	fields := []shp.Field{shp.StringField("NAME", 8), shp.StringField("P", 8)}
+	rows := [][]any{{"alpha", "10"}, {"bravo", "1000"}, {"charlie", "20"}}
+	filename := writeShapefile(t, shp.POLYGON, shapes, fields, rows)
+	markdeleted(t, filename, 1)
+	open := func() *shp.Reader { return openShapefile(t, filename) }
+	polygons := func(markup string) int { return strings.Count(markup, "<polygon ") }
+	c := NewConfig("polygon", "red", 0)
+
+	var b strings.Builder
+	st, err := Render(&b, open(), RenderOptions{Geometry: unit, MapType: "polygon", Color: "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := polygons(b.String()); n != 2 || st.Deleted != 1 {
+		t.Errorf("Render: %d polygons and %d deleted, want 2 and 1", n, st.Deleted)
+	}
+
+	b.Reset()
+	cc := ChoroplethConfig{Field: "P", Palette: Palette{"#000000", "#ffffff"}}
+	if err := Choropleth(&b, open(), unit, c, cc); err != nil {
+		t.Fatal(err)
+	}
+	if n := polygons(b.String()); n != 2 || !strings.Contains(b.String(), `color="#ffffff"`) {
+		t.Errorf("Choropleth: %d polygons, want 2 with the deleted value out of the range:\n%s", n, b.String())
+	}
+
+	b.Reset()
+	if err := Categorical(&b, open(), unit, c, CategoryConfig{Field: "NAME", Palette: Palette{"blue"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := polygons(b.String()); n != 2 {
+		t.Errorf("Categorical: %d polygons, want 2", n)
+	}
+
+	b.Reset()
+	if err := RenderLabels(&b, open(), unit, "NAME", c); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "bravo") || !strings.Contains(b.String(), "alpha") {
+		t.Errorf("RenderLabels:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := RenderAttributeTable(&b, open(), []string{"NAME"}, 0, 100, 5, c); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "bravo") || !strings.Contains(b.String(), "charlie") {
+		t.Errorf("RenderAttributeTable:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := RenderDotDensity(&b, open(), unit, "P", 10, NewConfig("dot", "red", 0.2)); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "<ellipse "); n != 3 {
+		t.Errorf("RenderDotDensity: %d dots, want 1 for alpha and 2 for charlie", n)
+	}
+
+	g, err := DataBounds(open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Geometry{Longmin: 0, Longmax: 5, Latmin: 0, Latmax: 5}); g != want {
+		t.Errorf("DataBounds = %+v, want %+v", g, want)
+	}
+}
+
+// TestDeletedUpperCase finds the table of a shapefile named in capitals
+// as go-shp does, by the last three characters of the name
+func TestDeletedUpperCase(t *testing.T) {
+	filename := writeShapefile(t, shp.POLYGON, []shp.Shape{square(0, 0, 1), square(2, 2, 1)}, []shp.Field{shp.StringField("NAME", 4)}, [][]any{{"a"}, {"b"}})
+	markdeleted(t, filename, 0)
+	upper := strings.TrimSuffix(filename, "shp") + "SHP"
+	flags, err := DeletedRecords(upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []bool{true, false}; !slices.Equal(flags, want) {
+		t.Errorf("DeletedRecords(%q) = %v, want %v", upper, flags, want)
+	}
+}
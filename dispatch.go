@@ -0,0 +1,150 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderFunc writes the markup for one shape, mapped from g to the
+// Config screen box. Implementations should report shapes they cannot draw
+// through the Config Logger rather than fail.
+type RenderFunc func(dest io.Writer, s shp.Shape, g Geometry, c Config)
+
+// the renderer registry is safe for concurrent use; by default it covers the
+// 2D types: polygons, polylines, multipoints and points
+var (
+	renderermu sync.RWMutex
+	renderers  = map[shp.ShapeType]RenderFunc{
+		shp.POLYGON: func(w io.Writer, s shp.Shape, g Geometry, c Config) {
+			PolygonCoords(w, s.(*shp.Polygon), g, c)
+		},
+		shp.POLYLINE: func(w io.Writer, s shp.Shape, g Geometry, c Config) {
+			PolylineCoords(w, s.(*shp.PolyLine), g, c)
+		},
+		shp.MULTIPOINT: func(w io.Writer, s shp.Shape, g Geometry, c Config) {
+			MultipointCoords(w, s.(*shp.MultiPoint), g, c)
+		},
+		shp.POINT: func(w io.Writer, s shp.Shape, g Geometry, c Config) {
+			PointCoords(w, s.(*shp.Point), g, c)
+		},
+	}
+)
+
+// RegisterRenderer adds or replaces the renderer for a shape type, such as
+// shp.POINTZ, which is otherwise skipped. A nil fn removes it.
+// It is safe to call concurrently with rendering.
+func RegisterRenderer(shapeType shp.ShapeType, fn RenderFunc) {
+	renderermu.Lock()
+	defer renderermu.Unlock()
+	if fn == nil {
+		delete(renderers, shapeType)
+		return
+	}
+	renderers[shapeType] = fn
+}
+
+// renderer returns the renderer registered for a shape type
+func renderer(shapeType shp.ShapeType) (RenderFunc, bool) {
+	renderermu.RLock()
+	defer renderermu.RUnlock()
+	fn, ok := renderers[shapeType]
+	return fn, ok
+}
+
+// shapetype returns the shape type of a go-shp shape
+func shapetype(s shp.Shape) shp.ShapeType {
+	switch s.(type) {
+	case *shp.Point:
+		return shp.POINT
+	case *shp.PolyLine:
+		return shp.POLYLINE
+	case *shp.Polygon:
+		return shp.POLYGON
+	case *shp.MultiPoint:
+		return shp.MULTIPOINT
+	case *shp.PointZ:
+		return shp.POINTZ
+	case *shp.PolyLineZ:
+		return shp.POLYLINEZ
+	case *shp.PolygonZ:
+		return shp.POLYGONZ
+	case *shp.MultiPointZ:
+		return shp.MULTIPOINTZ
+	case *shp.PointM:
+		return shp.POINTM
+	case *shp.PolyLineM:
+		return shp.POLYLINEM
+	case *shp.PolygonM:
+		return shp.POLYGONM
+	case *shp.MultiPointM:
+		return shp.MULTIPOINTM
+	case *shp.MultiPatch:
+		return shp.MULTIPATCH
+	}
+	return shp.NULL
+}
+
+// shapevertices counts the points of the 2D shape types
+func shapevertices(s shp.Shape) int {
+	switch v := s.(type) {
+	case *shp.Polygon:
+		return len(v.Points)
+	case *shp.PolyLine:
+		return len(v.Points)
+	case *shp.MultiPoint:
+		return len(v.Points)
+	case *shp.Point:
+		return 1
+	}
+	return 0
+}
+
+// shapepoints returns the points of a shape
+func shapepoints(s shp.Shape) []shp.Point {
+	switch v := s.(type) {
+	case *shp.Polygon:
+		return v.Points
+	case *shp.PolyLine:
+		return v.Points
+	case *shp.MultiPoint:
+		return v.Points
+	case *shp.Point:
+		return []shp.Point{*v}
+	}
+	return nil
+}
+
+// RenderShape draws a shape, with its shadow if configured, using the
+// renderer registered for its type; null and unregistered shapes are skipped,
+// as are shapes with more points than Config.MaxVerticesPerFeature
+func RenderShape(dest io.Writer, s shp.Shape, g Geometry, c Config) {
+	st := shapetype(s)
+	if st == shp.NULL {
+		if _, ok := s.(*shp.Null); ok || s == nil {
+			c.skip("null shape")
+		} else if c.reporting() {
+			c.skip(fmt.Sprintf("unsupported shape type %T", s))
+		}
+		return
+	}
+	fn, ok := renderer(st)
+	if !ok {
+		if c.reporting() {
+			c.skip(fmt.Sprintf("unsupported shape type %T", s))
+		}
+		return
+	}
+	if n := shapevertices(s); c.MaxVerticesPerFeature > 0 && n > c.MaxVerticesPerFeature {
+		if c.reporting() {
+			c.skip(fmt.Sprintf("%d vertices is over the limit of %d", n, c.MaxVerticesPerFeature))
+		}
+		return
+	}
+	if c.Shadow.Color != "" {
+		fn(dest, s, g, c.shadow())
+	}
+	fn(dest, s, g, c)
+}
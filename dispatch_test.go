@@ -0,0 +1,47 @@
+package shpdeck
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMaxVerticesPerFeature(t *testing.T) {
+	poly := makePolygon(1000)
+	tests := []struct {
+		name  string
+		limit int
+		drawn bool
+	}{
+		{"unlimited", 0, true},
+		{"under the limit", 5000, true},
+		{"at the limit", len(poly.Points), true},
+		{"over the limit", 999, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			c := NewConfig("polygon", "red", 0)
+			c.MaxVerticesPerFeature = tt.limit
+			c.Logger = func(s string) { logged = append(logged, s) }
+			var b strings.Builder
+			RenderShape(&b, poly, world, c)
+			if drawn := strings.Contains(b.String(), "<polygon "); drawn != tt.drawn {
+				t.Errorf("drawn: %v, want %v", drawn, tt.drawn)
+			}
+			if warned := len(logged) == 1 && strings.Contains(logged[0], "over the limit"); warned == tt.drawn {
+				t.Errorf("logged %q", logged)
+			}
+		})
+	}
+}
+
+// TestSkipUnlogged costs nothing to skip a feature with no Logger or stats
+func TestSkipUnlogged(t *testing.T) {
+	poly := makePolygon(1000)
+	c := NewConfig("polygon", "red", 0)
+	c.MaxVerticesPerFeature = 999
+	if n := testing.AllocsPerRun(100, func() { RenderShape(io.Discard, poly, world, c) }); n != 0 {
+		t.Errorf("%v allocations to skip a feature", n)
+	}
+}
@@ -0,0 +1,87 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderDotDensity draws, for each polygon, one dot per perDot of the numeric
+// field, scattered uniformly over the mapped polygon (holes excluded). Dots
+// use the Config color and size. Placement is random but repeatable:
+// the same Config.Seed gives the same map.
+func RenderDotDensity(dest io.Writer, r *shp.Reader, g Geometry, field string, perDot float64, c Config) error {
+	c = c.withscreen(g)
+	fi := fieldIndex(r, field)
+	if fi < 0 {
+		return fmt.Errorf("dot density: no field named %q", field)
+	}
+	if perDot <= 0 {
+		return fmt.Errorf("dot density: value per dot must be positive, not %v", perDot)
+	}
+	rng := rand.New(rand.NewPCG(c.Seed, c.Seed))
+	fill, op := colorattr(c.color)
+	deleted := readerdeleted(r)
+	for r.Next() {
+		n, s := r.Shape()
+		fc := c
+		fc.record = n
+		if isdeleted(deleted, n) {
+			fc.skip("deleted in the DBF")
+			continue
+		}
+		p, ok := s.(*shp.Polygon)
+		if !ok {
+			continue
+		}
+		v, ok := fieldValue(r, n, fi, c.ParseValue)
+		if !ok {
+			continue
+		}
+		dots := int(math.Round(v / perDot))
+		rings := screenparts(partpoints(p.Points, p.Parts, p.NumPoints), g, fc)
+		xs, ys := make([][]float64, len(rings)), make([][]float64, len(rings))
+		for i, ring := range rings {
+			for _, pt := range ring {
+				xs[i] = append(xs[i], pt.X)
+				ys[i] = append(ys[i], pt.Y)
+			}
+		}
+		if len(rings) == 0 || len(xs[0]) == 0 {
+			continue
+		}
+		xmin, xmax, ymin, ymax := bounds(xs[0], ys[0])
+		for i := 1; i < len(rings); i++ {
+			if len(xs[i]) == 0 {
+				continue
+			}
+			x0, x1, y0, y1 := bounds(xs[i], ys[i])
+			xmin, xmax = min(xmin, x0), max(xmax, x1)
+			ymin, ymax = min(ymin, y0), max(ymax, y1)
+		}
+		// rejection sampling over the bounding box, giving up on slivers
+		placed := 0
+		for tries := 0; placed < dots && tries < 100*dots; tries++ {
+			x := xmin + rng.Float64()*(xmax-xmin)
+			y := ymin + rng.Float64()*(ymax-ymin)
+			in := false
+			for i := range rings {
+				if pointinpoly(x, y, xs[i], ys[i]) {
+					in = !in
+				}
+			}
+			if in {
+				fc.dot(dest, x, y, fill, op, fc.shapesize)
+				placed++
+			}
+		}
+		fc.vertices(placed)
+		if placed < dots && fc.reporting() {
+			fc.skip(fmt.Sprintf("placed %d of %d dots", placed, dots))
+		}
+	}
+	return r.Err()
+}
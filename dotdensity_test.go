@@ -0,0 +1,81 @@
+package shpdeck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// ellipses parses the centers of the deck ellipses in markup
+func ellipses(tb testing.TB, markup string) [][2]float64 {
+	tb.Helper()
+	var pts [][2]float64
+	for _, line := range strings.Split(markup, "\n") {
+		if !strings.HasPrefix(line, "<ellipse ") {
+			continue
+		}
+		var p [2]float64
+		if _, err := fmt.Sscanf(line, `<ellipse xp="%g" yp="%g"`, &p[0], &p[1]); err != nil {
+			tb.Fatalf("%s: %v", line, err)
+		}
+		pts = append(pts, p)
+	}
+	return pts
+}
+
+// TestRenderDotDensity places one dot per perDot of the field inside each
+// polygon, outside its holes, the same for the same seed
+func TestRenderDotDensity(t *testing.T) {
+	holed := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, holeA}))
+	shapes := []shp.Shape{&holed, square(6, 0, 2), square(6, 6, 2)}
+	rows := [][]any{{"20"}, {""}, {"7"}}
+	filename := writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("N", 4)}, rows)
+	render := func(seed uint64) string {
+		c := NewConfig("polygon", "red", 0.1)
+		c.Seed = seed
+		var b strings.Builder
+		if err := RenderDotDensity(&b, openShapefile(t, filename), unit, "N", 2, c); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	markup := render(1)
+	var inA, inB int
+	for _, p := range ellipses(t, markup) {
+		switch {
+		case p[0] > 10 && p[0] < 30 && p[1] > 10 && p[1] < 30:
+			t.Errorf("dot %v is in the hole", p)
+		case p[0] < 40 && p[1] < 40:
+			inA++
+		case p[0] > 60 && p[0] < 80 && p[1] > 60 && p[1] < 80:
+			inB++
+		default:
+			t.Errorf("dot %v is outside the polygons", p)
+		}
+	}
+	if inA != 10 || inB != 4 {
+		t.Errorf("%d and %d dots, want 10 and 4", inA, inB)
+	}
+	if render(1) != markup {
+		t.Error("the same seed places different dots")
+	}
+	if render(2) == markup {
+		t.Error("another seed places the same dots")
+	}
+
+	for _, tt := range []struct {
+		field  string
+		perDot float64
+		want   string
+	}{
+		{"X", 2, `no field named "X"`},
+		{"N", 0, "must be positive"},
+	} {
+		err := RenderDotDensity(new(strings.Builder), openShapefile(t, filename), unit, tt.field, tt.perDot, NewConfig("polygon", "red", 0.1))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error %v, want %q", err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package shpdeck
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// cp1252 maps the bytes 0x80-0x9f of Windows-1252 to runes; the rest of
+// its upper half is the same as Latin-1. Unassigned bytes map to U+FFFD.
+var cp1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// CodePage returns the encoding declared for a shapefile's DBF text: the
+// contents of its .cpg file, or else the language driver byte of the DBF
+// header, as a name DecodeText understands, or "" when neither says.
+func CodePage(filename string) string {
+	if b, err := os.ReadFile(strings.TrimSuffix(dbfname(filename), "dbf") + "cpg"); err == nil {
+		return strings.TrimSpace(string(b))
+	}
+	f, err := os.Open(dbfname(filename))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	ldid := make([]byte, 1)
+	if _, err := f.ReadAt(ldid, 29); err != nil {
+		return ""
+	}
+	switch ldid[0] {
+	case 0x03, 0x57:
+		return "windows-1252"
+	}
+	return ""
+}
+
+// DecodeText converts DBF text in the named encoding to UTF-8. Latin-1
+// (ISO-8859-1) and Windows-1252 are converted, under their common names and
+// the .cpg spellings "88591" and "1252". For UTF-8, and for the empty or any
+// other name, valid UTF-8 is returned as is, and text that is not valid UTF-8
+// is read as Windows-1252, the usual encoding of older files.
+func DecodeText(s, encoding string) string {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(encoding), "_", "-")) {
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1", "88591", "8859-1":
+		return decodebytes(s, false)
+	case "windows-1252", "cp1252", "1252", "ansi 1252":
+		return decodebytes(s, true)
+	}
+	if utf8.ValidString(s) {
+		return s
+	}
+	return decodebytes(s, true)
+}
+
+// decodebytes reads each byte of s as a rune of Latin-1, or of Windows-1252
+func decodebytes(s string, windows bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case windows && c < 0xa0:
+			b.WriteRune(cp1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// attr reads a text attribute, decoded from Config.Encoding
+func (c Config) attr(r *shp.Reader, row, field int) string {
+	return DecodeText(r.ReadAttribute(row, field), c.Encoding)
+}
@@ -0,0 +1,53 @@
+package shpdeck
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name, s, encoding, want string
+	}{
+		{"latin-1", "Mal\xe9 \xc5land", "ISO-8859-1", "Malé Åland"},
+		{"latin-1 cpg spelling", "S\xe3o Tom\xe9", "88591", "São Tomé"},
+		{"latin-1 control range", "\x80", "latin1", "\u0080"},
+		{"windows-1252", "\x93Quoted\x94 \x80", "1252", "“Quoted” €"},
+		{"utf-8", "Malé", "UTF-8", "Malé"},
+		{"undeclared utf-8", "Malé", "", "Malé"},
+		{"undeclared latin-1", "Mal\xe9", "", "Malé"},
+		{"ascii", "Paris", "88591", "Paris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeText(tt.s, tt.encoding); got != tt.want {
+				t.Errorf("DecodeText(%q, %q) = %q, want %q", tt.s, tt.encoding, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCodePage reads a Latin-1 name from a table whose .cpg declares it
+func TestCodePage(t *testing.T) {
+	name := writeShapefile(t, shp.POLYGON, []shp.Shape{square(0, 0, 1)}, []shp.Field{shp.StringField("NAME", 16)}, [][]any{{"Mal\xe9"}})
+	if cp := CodePage(name); cp != "" {
+		t.Errorf("CodePage = %q with no .cpg, want \"\"", cp)
+	}
+	if err := os.WriteFile(strings.TrimSuffix(name, ".shp")+".cpg", []byte("88591\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig("polygon", "red", 0)
+	c.Encoding = CodePage(name)
+	if c.Encoding != "88591" {
+		t.Errorf("CodePage = %q, want \"88591\"", c.Encoding)
+	}
+	if cp := CodePage(strings.TrimSuffix(name, "shp") + "SHP"); cp != "88591" {
+		t.Errorf("CodePage of the name in capitals = %q, want \"88591\"", cp)
+	}
+	if got := c.attr(openShapefile(t, name), 0, 0); got != "Malé" {
+		t.Errorf("NAME is %q, want \"Malé\"", got)
+	}
+}
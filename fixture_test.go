@@ -0,0 +1,135 @@
+package shpdeck
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// makePolygon makes a deterministic star-shaped polygon of n vertices
+// (at least 3) within longitude and latitude -10..10, for large fixtures
+func makePolygon(n int) *shp.Polygon {
+	n = max(n, 3)
+	ring := make([]shp.Point, n+1)
+	for i := range n {
+		a := -2 * math.Pi * float64(i) / float64(n) // clockwise, the outer ring winding
+		r := 10.0
+		if i%2 == 1 {
+			r = 6
+		}
+		ring[i] = shp.Point{X: r * math.Cos(a), Y: r * math.Sin(a)}
+	}
+	ring[n] = ring[0]
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{ring}))
+	return &poly
+}
+
+// makePolyline makes a deterministic zig-zag line of n vertices (at least 2)
+// split into parts of at most partSize vertices
+func makePolyline(n, partSize int) *shp.PolyLine {
+	n, partSize = max(n, 2), max(partSize, 2)
+	var parts [][]shp.Point
+	var part []shp.Point
+	for i := range n {
+		part = append(part, shp.Point{X: -10 + 20*float64(i)/float64(n), Y: 5 * math.Sin(float64(i))})
+		if len(part) == partSize {
+			parts = append(parts, part)
+			part = nil
+		}
+	}
+	switch {
+	case len(part) == 1 && len(parts) > 0:
+		parts[len(parts)-1] = append(parts[len(parts)-1], part[0])
+	case len(part) > 0:
+		parts = append(parts, part)
+	}
+	return shp.NewPolyLine(parts)
+}
+
+// makeMultiPoint makes n deterministic points scattered within longitude and latitude -10..10
+func makeMultiPoint(n int) *shp.MultiPoint {
+	pts := make([]shp.Point, n)
+	for i := range pts {
+		// golden angle spiral
+		r := 10 * math.Sqrt(float64(i)/float64(max(n, 1)))
+		a := float64(i) * 2.399963229728653
+		pts[i] = shp.Point{X: r * math.Cos(a), Y: r * math.Sin(a)}
+	}
+	return &shp.MultiPoint{Box: shp.BBoxFromPoints(pts), NumPoints: int32(n), Points: pts}
+}
+
+// writeShapefile writes shapes of type st, with a DBF of fields holding rows,
+// to a temporary directory and returns the name of the .shp file
+func writeShapefile(tb testing.TB, st shp.ShapeType, shapes []shp.Shape, fields []shp.Field, rows [][]any) string {
+	tb.Helper()
+	base := filepath.Join(tb.TempDir(), "fixture")
+	w, err := shp.Create(base+".shp", st)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := w.SetFields(fields); err != nil {
+		tb.Fatal(err)
+	}
+	for _, s := range shapes {
+		w.Write(s)
+	}
+	for row, values := range rows {
+		for field, v := range values {
+			if s, ok := v.(string); ok {
+				// pad text with spaces as DBF writers do; go-shp leaves NULs
+				v = s + strings.Repeat(" ", max(0, int(fields[field].Size)-len(s)))
+			}
+			if err := w.WriteAttribute(row, field, v); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	w.Close()
+	// go-shp names the table "fixturedbf"
+	if err := os.Rename(base+"dbf", base+".dbf"); err != nil {
+		tb.Fatal(err)
+	}
+	return base + ".shp"
+}
+
+// openShapefile opens a shapefile, closing it when the test ends
+func openShapefile(tb testing.TB, filename string) *shp.Reader {
+	tb.Helper()
+	r, err := shp.Open(filename)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { r.Close() })
+	return r
+}
+
+// square makes a closed clockwise square polygon with its lower left corner at (x, y)
+func square(x, y, size float64) *shp.Polygon {
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{{{X: x, Y: y}, {X: x, Y: y + size}, {X: x + size, Y: y + size}, {X: x + size, Y: y}, {X: x, Y: y}}}))
+	return &poly
+}
+
+// unit maps longitude and latitude 0..10 onto a 0..100 screen box
+var unit = Geometry{Xmin: 0, Xmax: 100, Ymin: 0, Ymax: 100, Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+
+// lineelements parses the x1, y1, x2, y2 of each deck <line> in markup
+func lineelements(tb testing.TB, markup string) [][4]float64 {
+	tb.Helper()
+	var out [][4]float64
+	for _, line := range strings.Split(markup, "\n") {
+		if !strings.HasPrefix(line, "<line ") {
+			continue
+		}
+		var s [4]float64
+		if _, err := fmt.Sscanf(line, `<line xp1="%g" yp1="%g" xp2="%g" yp2="%g"`, &s[0], &s[1], &s[2], &s[3]); err != nil {
+			tb.Fatalf("%s: %v", line, err)
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Flow is the style of the arcs drawn by RenderFlows
+type Flow struct {
+	// Curve is the height of each arc as a fraction of its length,
+	// bending to the left of the direction of travel; zero uses 0.2
+	// and a negative value bends to the right
+	Curve float64
+	// Volumes, if set, has one value per flow, and scales the arc widths
+	// so the largest volume is MaxWidth wide (default 1)
+	Volumes  []float64
+	MaxWidth float64
+}
+
+// RenderFlows draws a curved arc from each origin to its destination, in the
+// Config color and size, styled by Config.Flow. The slices pair up by index
+// and must be the same length.
+func RenderFlows(dest io.Writer, origins, destinations []shp.Point, g Geometry, c Config) error {
+	c = c.withscreen(g)
+	if len(origins) != len(destinations) {
+		return fmt.Errorf("flows: %d origins but %d destinations", len(origins), len(destinations))
+	}
+	vols := c.Flow.Volumes
+	if len(vols) > 0 && len(vols) != len(origins) {
+		return fmt.Errorf("flows: %d volumes for %d flows", len(vols), len(origins))
+	}
+	curve := c.Flow.Curve
+	if curve == 0 {
+		curve = 0.2
+	}
+	maxw := c.Flow.MaxWidth
+	if maxw <= 0 {
+		maxw = 1
+	}
+	maxv := 0.0
+	if len(vols) > 0 {
+		maxv = slices.Max(vols)
+	}
+	fill, op := colorattr(c.color)
+	for i := range origins {
+		x1, y1 := c.mappoint(origins[i], g)
+		x3, y3 := c.mappoint(destinations[i], g)
+		// the control point sits off the midpoint, perpendicular to the chord
+		// at twice the arc height, as a quadratic peaks halfway to its control
+		dx, dy := x3-x1, y3-y1
+		x2 := (x1+x3)/2 - dy*curve*2
+		y2 := (y1+y3)/2 + dx*curve*2
+		width := c.shapesize
+		if maxv > 0 {
+			width = maxw * math.Max(vols[i], 0) / maxv
+		}
+		c.curve(dest, x1, y1, x2, y2, x3, y3, fill, op, width)
+		c.vertices(2)
+	}
+	return nil
+}
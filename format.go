@@ -0,0 +1,223 @@
+package shpdeck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Format selects the markup written by the renderers
+type Format int
+
+const (
+	// Deck writes deck markup (the default)
+	Deck Format = iota
+	// SVG writes SVG elements; since SVG y grows downward,
+	// use a screen box with Ymin > Ymax for north-up maps
+	SVG
+)
+
+const (
+	svglinefmt  = "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" stroke=\"%s\" stroke-opacity=\"%s\" stroke-width=\"%.3f\"/>\n"
+	svgdotfmt   = "<circle cx=\"%s\" cy=\"%s\" r=\"%.3f\" fill=\"%s\" fill-opacity=\"%s\"/>\n"
+	svgrectfmt  = "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"%s\" fill-opacity=\"%s\"/>\n"
+	svgcurvefmt = "<path d=\"M %s %s Q %s %s %s %s\" fill=\"none\" stroke=\"%s\" stroke-opacity=\"%s\" stroke-width=\"%.3f\"/>\n"
+	curvefmt    = "<curve xp1=\"%s\" yp1=\"%s\" xp2=\"%s\" yp2=\"%s\" xp3=\"%s\" yp3=\"%s\" color=\"%s\" opacity=\"%s\" sp=\"%.3f\"/>\n"
+)
+
+// svgop converts a deck opacity (0-100) to an SVG opacity (0-1)
+func svgop(op string) string {
+	v, err := strconv.ParseFloat(op, 64)
+	if err != nil {
+		return "1"
+	}
+	return strconv.FormatFloat(clamp(v/100, 0, 1), 'f', -1, 64)
+}
+
+// line writes a line segment in the configured format
+func (c Config) line(w io.Writer, x1, y1, x2, y2 float64, fill, op string, size float64) {
+	p := c.decimals(7)
+	if c.Format == SVG {
+		fmt.Fprintf(w, svglinefmt, num(x1, p), num(y1, p), num(x2, p), num(y2, p), fill, svgop(op), size)
+		return
+	}
+	fmt.Fprintf(w, linefmt, num(x1, p), num(y1, p), num(x2, p), num(y2, p), fill, op, size)
+}
+
+// curve writes a quadratic curve from (x1, y1) to (x3, y3), bending toward
+// the control point (x2, y2), in the configured format
+func (c Config) curve(w io.Writer, x1, y1, x2, y2, x3, y3 float64, fill, op string, size float64) {
+	p := c.decimals(7)
+	if c.Format == SVG {
+		fmt.Fprintf(w, svgcurvefmt, num(x1, p), num(y1, p), num(x2, p), num(y2, p), num(x3, p), num(y3, p), fill, svgop(op), size)
+		return
+	}
+	fmt.Fprintf(w, curvefmt, num(x1, p), num(y1, p), num(x2, p), num(y2, p), num(x3, p), num(y3, p), fill, op, size)
+}
+
+// dot writes a circle of diameter size in the configured format
+func (c Config) dot(w io.Writer, x, y float64, fill, op string, size float64) {
+	p := c.decimals(7)
+	if c.Format == SVG {
+		fmt.Fprintf(w, svgdotfmt, num(x, p), num(y, p), size/2, fill, svgop(op))
+		return
+	}
+	fmt.Fprintf(w, dotfmt, num(x, p), num(y, p), fill, op, size)
+}
+
+// rect writes a rectangle of width w and height h centered on (x, y)
+// in the configured format
+func (c Config) rect(dest io.Writer, x, y, w, h float64, fill, op string) {
+	p := c.decimals(5)
+	if c.Format == SVG {
+		fmt.Fprintf(dest, svgrectfmt, num(x-w/2, p), num(y-h/2, p), num(w, p), num(h, p), fill, svgop(op))
+		return
+	}
+	fmt.Fprintf(dest, rectfmt, num(x, p), num(y, p), num(w, p), num(h, p), fill, op)
+}
+
+// svgshape writes SVG markup according to the specified shape
+func svgshape(w io.Writer, x, y []float64, shape string, c Config) {
+	fill, op := colorattr(c.color)
+	switch shape {
+	case "p", "poly", "region", "polygon":
+		if len(x) < 3 || len(x) != len(y) {
+			return
+		}
+		prec := c.precision()
+		buf := fmt.Appendf(nil, "<polygon fill=\"%s\" fill-opacity=\"%s\" points=\"", fill, svgop(op))
+		for i := range x {
+			if i > 0 {
+				buf = append(buf, ' ')
+			}
+			buf = appendcoord(buf, x[i], prec)
+			buf = appendcoord(append(buf, ','), y[i], prec)
+		}
+		w.Write(append(buf, "\"/>\n"...))
+	case "l", "line", "border":
+		lx := len(x)
+		if lx < 2 {
+			return
+		}
+		for i := 0; i < lx-1; i++ {
+			c.line(w, x[i], y[i], x[i+1], y[i+1], fill, op, c.shapesize)
+		}
+	case "d", "dot", "circle":
+		if c.GlowRings > 0 {
+			for i := range x {
+				deckglow(w, x[i], y[i], c)
+			}
+			return
+		}
+		for i := range x {
+			c.dot(w, x[i], y[i], fill, op, c.shapesize)
+		}
+	}
+}
+
+// Target is one destination of RenderTargets
+type Target struct {
+	W      io.Writer
+	Format Format
+}
+
+// errWriter remembers the first write error and drops output after it
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
+
+// RenderTargets renders every record of r to each target, in its own format,
+// reading the shapefile once; each target gets what RenderReaders would write
+// to it alone. A write error on one target stops output to that target only;
+// all errors are returned together. There are one Stats per target.
+func RenderTargets(targets []Target, r *shp.Reader, g Geometry, c Config) ([]Stats, error) {
+	stats := make([]Stats, len(targets))
+	outs := make([]*output, len(targets))
+	cws := make([]*countWriter, len(targets))
+	for i, t := range targets {
+		ew := &errWriter{w: t.W}
+		cws[i] = &countWriter{w: ew}
+		tc := c
+		tc.Format = t.Format
+		boundscomment(cws[i], g, tc)
+		outs[i] = &output{w: cws[i], format: t.Format, st: &stats[i], ew: ew}
+	}
+	errs := []error{renderoutputs(outs, r, g, c, nil)}
+	for i, o := range outs {
+		stats[i].Bytes = cws[i].n
+		if o.ew.err != nil {
+			errs = append(errs, fmt.Errorf("target %d: %w", i, o.ew.err))
+		}
+	}
+	return stats, errors.Join(errs...)
+}
+
+// begingroup starts a named group in the configured format
+func (c Config) begingroup(w io.Writer, name string) {
+	if c.Format == SVG {
+		fmt.Fprintf(w, "<g id=\"%s\">\n", xmlesc(name))
+		return
+	}
+	BeginGroup(w, name)
+}
+
+// endgroup ends a group started by begingroup
+func (c Config) endgroup(w io.Writer) {
+	if c.Format == SVG {
+		fmt.Fprintln(w, "</g>")
+		return
+	}
+	EndGroup(w)
+}
+
+// svgpath writes the rings of a polygon as the subpaths of one SVG path,
+// so holes and islands belong to a single element. Each outer ring is
+// joined with its holes and mapped as mapring maps it, so MinSegment,
+// BufferInset, ClipToScreen and FillPattern apply as they do to deck.
+func svgpath(w io.Writer, rings [][]shp.Point, g Geometry, c Config) {
+	var pieces [][2][]float64
+	for _, group := range ringgroups(normalizewinding(rings, c.AssumeWinding)) {
+		pieces = append(pieces, mappedpieces(bridgeholes(group), g, c, true)...)
+	}
+	if len(pieces) == 0 {
+		return
+	}
+	fill, op := colorattr(c.color)
+	prec := c.precision()
+	buf := fmt.Appendf(nil, "<path fill=\"%s\" fill-opacity=\"%s\" fill-rule=\"evenodd\" d=\"", fill, svgop(op))
+	for i, piece := range pieces {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		for j := range piece[0] {
+			if j == 0 {
+				buf = append(buf, 'M')
+			} else {
+				buf = append(buf, " L"...)
+			}
+			buf = appendcoord(append(buf, ' '), piece[0][j], prec)
+			buf = appendcoord(append(buf, ' '), piece[1][j], prec)
+		}
+		buf = append(buf, " Z"...)
+		c.vertices(len(piece[0]))
+	}
+	w.Write(append(buf, "\"/>\n"...))
+	if c.FillPattern != "" {
+		for _, piece := range pieces {
+			deckpattern(w, piece[0], piece[1], c)
+		}
+	}
+}
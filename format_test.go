@@ -0,0 +1,146 @@
+package shpdeck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// failWriter accepts n bytes and then fails
+type failWriter struct{ n int }
+
+var errFull = errors.New("full")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		k := w.n
+		w.n = 0
+		return k, errFull
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+// TestRenderTargets writes to each target what RenderReaders writes in its
+// format, ordered, budgeted and commented the same, and stops only the
+// target whose writes fail
+func TestRenderTargets(t *testing.T) {
+	shapes := []shp.Shape{square(0, 0, 3), square(4, 0, 1), square(6, 0, 2), square(8, 8, 1)}
+	fields := []shp.Field{shp.StringField("Z", 4), shp.StringField("NAME", 8)}
+	rows := [][]any{{"2", "alpha"}, {"1", "bravo"}, {"3", "charlie"}, {"0", "delta"}}
+	filename := writeShapefile(t, shp.POLYGON, shapes, fields, rows)
+	markdeleted(t, filename, 3)
+	c := NewConfig("polygon", "red", 0)
+	c.ZOrder = "Z"
+	c.MaxElements = 2
+	c.CommentFields = []string{"NAME"}
+
+	want := map[Format]string{}
+	wantstats := map[Format]Stats{}
+	for _, f := range []Format{Deck, SVG} {
+		fc := c
+		fc.Format = f
+		var b strings.Builder
+		st, err := RenderReaders(&b, []*shp.Reader{openShapefile(t, filename)}, unit, fc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[f], wantstats[f] = b.String(), st
+	}
+
+	var deck, svg strings.Builder
+	targets := []Target{{W: &deck, Format: Deck}, {W: &svg, Format: SVG}, {W: &failWriter{n: 10}, Format: Deck}}
+	stats, err := RenderTargets(targets, openShapefile(t, filename), unit, c)
+	if !errors.Is(err, errFull) || !strings.Contains(err.Error(), "target 2") {
+		t.Errorf("error %v, want target 2 full", err)
+	}
+	for i, got := range []string{deck.String(), svg.String()} {
+		f := targets[i].Format
+		if got != want[f] {
+			t.Errorf("target %d:\n%s\nwant\n%s", i, got, want[f])
+		}
+		if stats[i].Features != 2 || stats[i].Deleted != 1 || stats[i].Bytes != int64(len(got)) {
+			t.Errorf("target %d stats %+v, want %+v", i, stats[i], wantstats[f])
+		}
+	}
+	if stats[2].Bytes > 10 {
+		t.Errorf("failed target wrote %d bytes, more than it accepted", stats[2].Bytes)
+	}
+}
+
+// coordinates returns the x coordinates of the deck polygons or the SVG path
+// in markup, and the number of pattern lines
+func coordinates(tb testing.TB, markup string) ([]float64, int) {
+	tb.Helper()
+	var xs []float64
+	lines := 0
+	for _, line := range strings.Split(markup, "\n") {
+		switch {
+		case strings.HasPrefix(line, "<line "):
+			lines++
+		case strings.HasPrefix(line, "<polygon "):
+			_, rest, _ := strings.Cut(line, ` xc="`)
+			rest, _, _ = strings.Cut(rest, `"`)
+			f := strings.Fields(rest)
+			for _, v := range f[:len(f)-1] { // deckpolygon repeats the last point
+				var x float64
+				fmt.Sscanf(v, "%g", &x)
+				xs = append(xs, x)
+			}
+		case strings.HasPrefix(line, "<path "):
+			_, rest, _ := strings.Cut(line, ` d="`)
+			rest, _, _ = strings.Cut(rest, `"`)
+			f := strings.Fields(rest)
+			for i := 0; i+1 < len(f); i++ {
+				if f[i] == "M" || f[i] == "L" {
+					var x float64
+					fmt.Sscanf(f[i+1], "%g", &x)
+					xs = append(xs, x)
+				}
+			}
+		}
+	}
+	return xs, lines
+}
+
+// TestSVGGroupedRings maps the rings of a grouped SVG path as deck maps
+// its polygons, whichever ring options are set
+func TestSVGGroupedRings(t *testing.T) {
+	offscreen := ring(shp.Point{X: 8, Y: 0}, shp.Point{X: 8, Y: 4}, shp.Point{X: 12, Y: 4}, shp.Point{X: 12, Y: 0})
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, holeA, offscreen}))
+	tests := []struct {
+		name string
+		set  func(*Config)
+	}{
+		{"plain", func(c *Config) {}},
+		{"MinSegment", func(c *Config) { c.MinSegment = 50 }},
+		{"BufferInset", func(c *Config) { c.BufferInset = 2 }},
+		{"ClipToScreen", func(c *Config) { c.ClipToScreen = true }},
+		{"FillPattern", func(c *Config) { c.FillPattern = PatternHatch; c.PatternSpacing = 10 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.GroupParts = true
+			tt.set(&c)
+			var deck, svg strings.Builder
+			RenderShape(&deck, &poly, unit, c)
+			c.Format = SVG
+			RenderShape(&svg, &poly, unit, c)
+			want, wantlines := coordinates(t, deck.String())
+			got, gotlines := coordinates(t, svg.String())
+			if len(got) != len(want) || gotlines != wantlines {
+				t.Fatalf("SVG has %d points and %d pattern lines, deck %d and %d:\n%s\n%s", len(got), gotlines, len(want), wantlines, svg.String(), deck.String())
+			}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-4 {
+					t.Fatalf("point %d at x %g, deck at %g", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderBackground fills the screen box of g with the Config color
+// (name:op for opacity), to be drawn before the features
+func RenderBackground(dest io.Writer, g Geometry, c Config) {
+	fill, op := colorattr(c.color)
+	box := c.screenbox(g)
+	x0, y0, x1, y1 := box[0].X, box[0].Y, box[2].X, box[2].Y
+	c.rect(dest, (x0+x1)/2, (y0+y1)/2, math.Abs(x1-x0), math.Abs(y1-y0), fill, op)
+}
+
+// RenderLocator draws a small locator map: the context layer in the inset's
+// screen box and geographic bounds, with the geographic extent of the main map
+// (highlightBounds) outlined in red on top.
+func RenderLocator(dest io.Writer, contextReader *shp.Reader, highlightBounds Geometry, inset Geometry, c Config) error {
+	if err := renderloop(dest, contextReader, inset, c, nil, nil); err != nil {
+		return err
+	}
+	h := highlightBounds
+	corners := []shp.Point{{X: h.Longmin, Y: h.Latmin}, {X: h.Longmin, Y: h.Latmax}, {X: h.Longmax, Y: h.Latmax}, {X: h.Longmax, Y: h.Latmin}, {X: h.Longmin, Y: h.Latmin}}
+	x := make([]float64, len(corners))
+	y := make([]float64, len(corners))
+	for i, p := range corners {
+		x[i], y[i] = c.mappoint(p, inset)
+	}
+	outline := c.withscreen(inset)
+	outline.color = "red"
+	outline.shapesize = max(c.shapesize, 0.2)
+	mapshape(dest, x, y, "line", outline)
+	return nil
+}
+
+// RenderFrame outlines the screen box of g in the Config color and size,
+// with ticks out from the bottom and left edges at the given longitudes and
+// latitudes, labeled in degrees in the Config locale. Ticks outside the
+// geographic bounds are left out.
+func RenderFrame(dest io.Writer, g Geometry, lonTicks, latTicks []float64, c Config) {
+	c = c.withscreen(g)
+	fill, op := colorattr(c.color)
+	x0, x1, y0, y1 := g.Xmin, g.Xmax, g.Ymin, g.Ymax
+	c.line(dest, x0, y0, x1, y0, fill, op, c.shapesize)
+	c.line(dest, x1, y0, x1, y1, fill, op, c.shapesize)
+	c.line(dest, x1, y1, x0, y1, fill, op, c.shapesize)
+	c.line(dest, x0, y1, x0, y0, fill, op, c.shapesize)
+	ts := c.textstyle(TextStyle{Color: c.color})
+	tick := ts.Size / 2
+	within := func(v, a, b float64) bool { return v >= min(a, b) && v <= max(a, b) }
+	label := func(v float64) string { return c.Locale.Format(v, -1) + "°" }
+	for _, lon := range lonTicks {
+		if !within(lon, g.Longmin, g.Longmax) {
+			continue
+		}
+		x := scalemap(lon, g.Longmin, g.Longmax, x0, x1, c.ScaleX)
+		c.line(dest, x, y0, x, y0-tick, fill, op, c.shapesize)
+		lt := ts
+		lt.Align = "center"
+		c.text(dest, lt, x, y0-tick-ts.Size, label(lon), 0)
+	}
+	for _, lat := range latTicks {
+		if !within(lat, g.Latmin, g.Latmax) {
+			continue
+		}
+		y := scalemap(lat, g.Latmin, g.Latmax, y0, y1, c.ScaleY)
+		c.line(dest, x0, y, x0-tick, y, fill, op, c.shapesize)
+		lt := ts
+		lt.Align = "right"
+		c.text(dest, lt, x0-tick*2, y-ts.Size/3, label(lat), 0)
+	}
+}
+
+// Stroke is a line color and width
+type Stroke struct {
+	Color string  // line color, with optional opacity as "name:op"
+	Width float64 // line width; zero uses 0.1
+}
+
+// RenderLandSea draws a two-tone basemap: the screen box filled with
+// seaColor, then the polygons of r filled with landColor, and then,
+// if Config.Outline has a color, the coastlines drawn over the land.
+func RenderLandSea(dest io.Writer, r *shp.Reader, g Geometry, landColor, seaColor string, c Config) error {
+	land := &recordBuffer{reader: r}
+	for r.Next() {
+		n, s := r.Shape()
+		land.recs = append(land.recs, record{row: n, shape: s})
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	c.color = seaColor
+	RenderBackground(dest, g, c)
+	c.maptype, c.color = "polygon", landColor
+	if err := renderloop(dest, land, g, c, nil, nil); err != nil || c.Outline.Color == "" {
+		return err
+	}
+	land.i = 0
+	c.maptype, c.color, c.shapesize = "line", c.Outline.Color, c.Outline.Width
+	if c.shapesize == 0 {
+		c.shapesize = 0.1
+	}
+	return renderloop(dest, land, g, c, nil, nil)
+}
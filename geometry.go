@@ -0,0 +1,50 @@
+package shpdeck
+
+import (
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Intersects reports whether the geographic bounds of g and other overlap.
+// Boxes that only touch along an edge or at a corner intersect.
+func (g Geometry) Intersects(other Geometry) bool {
+	return g.Longmin <= other.Longmax && other.Longmin <= g.Longmax &&
+		g.Latmin <= other.Latmax && other.Latmin <= g.Latmax
+}
+
+// Contains reports whether a coordinate lies within (or on the edge of) the geographic bounds
+func (g Geometry) Contains(lon, lat float64) bool {
+	return lon >= g.Longmin && lon <= g.Longmax && lat >= g.Latmin && lat <= g.Latmax
+}
+
+// boxgeo makes a Geometry whose geographic bounds are the box
+func boxgeo(b shp.Box) Geometry {
+	return Geometry{Longmin: b.MinX, Longmax: b.MaxX, Latmin: b.MinY, Latmax: b.MaxY}
+}
+
+// FitToCanvas returns dataBounds with the screen box that fits its geographic
+// bounds, at their own aspect ratio, into a canvas from (0, 0) to
+// (canvasW, canvasH) inset by margin on every side, and centered there.
+// Y grows upward, as in deck.
+func FitToCanvas(dataBounds Geometry, canvasW, canvasH, margin float64) Geometry {
+	g := dataBounds
+	w, h := canvasW-2*margin, canvasH-2*margin
+	dw, dh := g.Longmax-g.Longmin, g.Latmax-g.Latmin
+	scale := math.Inf(1)
+	if dw > 0 {
+		scale = w / dw
+	}
+	if dh > 0 {
+		scale = min(scale, h/dh)
+	}
+	if math.IsInf(scale, 1) {
+		scale = 0 // a single point sits at the center
+	}
+	sw, sh := dw*scale, dh*scale
+	g.Xmin = margin + (w-sw)/2
+	g.Xmax = g.Xmin + sw
+	g.Ymin = margin + (h-sh)/2
+	g.Ymax = g.Ymin + sh
+	return g
+}
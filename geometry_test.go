@@ -0,0 +1,79 @@
+package shpdeck
+
+import "testing"
+
+func TestGeometryIntersects(t *testing.T) {
+	box := Geometry{Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+	tests := []struct {
+		name  string
+		other Geometry
+		want  bool
+	}{
+		{"overlapping", Geometry{Longmin: 5, Longmax: 15, Latmin: 5, Latmax: 15}, true},
+		{"inside", Geometry{Longmin: 2, Longmax: 3, Latmin: 2, Latmax: 3}, true},
+		{"around", Geometry{Longmin: -5, Longmax: 15, Latmin: -5, Latmax: 15}, true},
+		{"touching an edge", Geometry{Longmin: 10, Longmax: 20, Latmin: 0, Latmax: 10}, true},
+		{"touching a corner", Geometry{Longmin: 10, Longmax: 20, Latmin: 10, Latmax: 20}, true},
+		{"touching the bottom", Geometry{Longmin: 0, Longmax: 10, Latmin: -10, Latmax: 0}, true},
+		{"just apart", Geometry{Longmin: 10.000001, Longmax: 20, Latmin: 0, Latmax: 10}, false},
+		{"apart in latitude", Geometry{Longmin: 0, Longmax: 10, Latmin: 11, Latmax: 20}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := box.Intersects(tt.other); got != tt.want {
+				t.Errorf("Intersects = %v, want %v", got, tt.want)
+			}
+			if got := tt.other.Intersects(box); got != tt.want {
+				t.Errorf("reversed Intersects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryContains(t *testing.T) {
+	box := Geometry{Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+	tests := []struct {
+		name     string
+		lon, lat float64
+		want     bool
+	}{
+		{"inside", 5, 5, true},
+		{"on an edge", 10, 5, true},
+		{"on a corner", 0, 0, true},
+		{"west", -0.1, 5, false},
+		{"north", 5, 10.1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := box.Contains(tt.lon, tt.lat); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lon, tt.lat, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitToCanvas(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         Geometry
+		w, h, margin float64
+		xmin, xmax   float64
+		ymin, ymax   float64
+	}{
+		{"wide", Geometry{Longmin: 0, Longmax: 40, Latmin: 0, Latmax: 10}, 100, 100, 10, 10, 90, 40, 60},
+		{"tall", Geometry{Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 40}, 200, 100, 5, 88.75, 111.25, 5, 95},
+		{"horizontal line", Geometry{Longmin: 0, Longmax: 10, Latmin: 5, Latmax: 5}, 100, 100, 0, 0, 100, 50, 50},
+		{"point", Geometry{Longmin: 3, Longmax: 3, Latmin: 3, Latmax: 3}, 100, 50, 0, 50, 50, 25, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := FitToCanvas(tt.data, tt.w, tt.h, tt.margin)
+			if g.Xmin != tt.xmin || g.Xmax != tt.xmax || g.Ymin != tt.ymin || g.Ymax != tt.ymax {
+				t.Errorf("screen box (%v, %v)-(%v, %v), want (%v, %v)-(%v, %v)", g.Xmin, g.Ymin, g.Xmax, g.Ymax, tt.xmin, tt.ymin, tt.xmax, tt.ymax)
+			}
+			if g.Longmin != tt.data.Longmin || g.Longmax != tt.data.Longmax || g.Latmin != tt.data.Latmin || g.Latmax != tt.data.Latmax {
+				t.Errorf("geographic bounds changed to %+v", g)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// graticulesteps is the number of segments in each graticule line,
+// so lines follow a WarpFunc or Projection
+const graticulesteps = 32
+
+// graticulelines returns the meridians and parallels at multiples of step
+// within the geographic bounds of g, each of n segments
+func graticulelines(g Geometry, step float64, n int) [][]shp.Point {
+	var lines [][]shp.Point
+	line := func(x0, y0, x1, y1 float64) {
+		pts := make([]shp.Point, n+1)
+		for i := range pts {
+			t := float64(i) / float64(n)
+			pts[i] = shp.Point{X: x0 + (x1-x0)*t, Y: y0 + (y1-y0)*t}
+		}
+		lines = append(lines, pts)
+	}
+	for lon := math.Ceil(g.Longmin/step) * step; lon <= g.Longmax; lon += step {
+		line(lon, g.Latmin, lon, g.Latmax)
+	}
+	for lat := math.Ceil(g.Latmin/step) * step; lat <= g.Latmax; lat += step {
+		line(g.Longmin, lat, g.Longmax, lat)
+	}
+	return lines
+}
+
+// RenderGraticule draws meridians and parallels every step degrees across
+// the geographic bounds of g, or as the Config Projection lays them out if it
+// is a Graticuler, as lines in the Config color and size. With any other
+// Projection the bounds of g are projected units, not degrees, so the lines
+// of the whole globe are projected and cut at the screen box instead.
+// The lines are clipped to Config.ClipPolygon and ClipMask, so that with
+// a mask from PolygonMask the grid appears only over the data.
+func RenderGraticule(dest io.Writer, g Geometry, step float64, c Config) {
+	c = c.withscreen(g)
+	if step <= 0 {
+		return
+	}
+	fill, op := colorattr(c.color)
+	regions := c.clipregions()
+	var lines [][]shp.Point
+	var screen []shp.Point
+	if gr, ok := c.Projection.(Graticuler); ok {
+		lines = gr.Graticule(step)
+	} else if c.Projection != nil {
+		world := Geometry{Longmin: -180, Longmax: 180, Latmin: -90, Latmax: 90}
+		lines, screen = graticulelines(world, step, 180), c.screenbox(g)
+	} else {
+		lines = graticulelines(g, step, graticulesteps)
+	}
+	for _, l := range lines {
+		pieces := [][]shp.Point{l}
+		if len(regions) > 0 {
+			pieces = nil
+			for _, region := range regions {
+				pieces = append(pieces, ClipPolyline(l, region)...)
+			}
+		}
+		for _, p := range pieces {
+			for _, m := range mappedline(p, g, c, screen) {
+				for i := 1; i < len(m); i++ {
+					if m[i-1] == m[i] {
+						continue // where a line grazes a clip corner
+					}
+					c.line(dest, m[i-1].X, m[i-1].Y, m[i].X, m[i].Y, fill, op, c.shapesize)
+				}
+			}
+			c.vertices(len(p))
+		}
+	}
+}
+
+// mappedline maps a graticule line to the screen, breaking it where the
+// projection has no point, and cuts it at the screen box if one is given
+func mappedline(line []shp.Point, g Geometry, c Config, screen []shp.Point) [][]shp.Point {
+	var runs [][]shp.Point
+	var run []shp.Point
+	for _, p := range line {
+		x, y := c.mappoint(p, g)
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			runs, run = append(runs, run), nil
+			continue
+		}
+		run = append(run, shp.Point{X: x, Y: y})
+	}
+	runs = append(runs, run)
+	if len(screen) == 0 {
+		return runs
+	}
+	var pieces [][]shp.Point
+	for _, r := range runs {
+		pieces = append(pieces, ClipPolyline(r, screen)...)
+	}
+	return pieces
+}
+
+// PolygonMask builds a clip mask covering every polygon of r not deleted, holes excluded,
+// for example to draw a graticule only over land. The mask is made of
+// triangles, and clipping tests every line against every triangle, so the
+// cost grows with both the number of lines and the detail of the polygons.
+func PolygonMask(r *shp.Reader) ([][]shp.Point, error) {
+	var mask [][]shp.Point
+	deleted := readerdeleted(r)
+	for r.Next() {
+		n, s := r.Shape()
+		if poly, ok := s.(*shp.Polygon); ok && !isdeleted(deleted, n) {
+			mask = append(mask, polygonmask(poly)...)
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("mask: no polygons")
+	}
+	return mask, nil
+}
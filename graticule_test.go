@@ -0,0 +1,115 @@
+package shpdeck
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPolarGraticule checks the polar azimuthal equidistant graticule:
+// parallels are circles around the pole and meridians run straight out from it
+func TestPolarGraticule(t *testing.T) {
+	tests := []struct {
+		name string
+		a    AzimuthalEquidistant
+		pole float64 // latitude of the pole at the center
+	}{
+		{"north", AzimuthalEquidistant{Lat0: 90, Extent: 60}, 90},
+		{"south", AzimuthalEquidistant{Lat0: -90, Extent: 60}, -90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meridians, parallels := 0, map[float64]bool{}
+			for _, l := range tt.a.Graticule(30) {
+				parallel := l[0].Y == l[len(l)-1].Y && l[0].X != l[len(l)-1].X
+				var dir [2]float64 // the direction of a meridian from the pole
+				for _, p := range l {
+					x, y := tt.a.Project(p.X, p.Y)
+					r := math.Hypot(x, y)
+					if math.Abs(r-math.Abs(tt.pole-p.Y)) > 1e-6 {
+						t.Fatalf("%v is %v from the pole, want %v", p, r, math.Abs(tt.pole-p.Y))
+					}
+					if r > 60+1e-6 {
+						t.Fatalf("%v is beyond the extent", p)
+					}
+					if parallel || r < 1e-9 {
+						continue
+					}
+					if dir == [2]float64{} {
+						dir = [2]float64{x / r, y / r}
+					} else if math.Abs(dir[0]*y-dir[1]*x) > 1e-6 || dir[0]*x+dir[1]*y < 0 {
+						t.Fatalf("meridian %v bends at %v", l[0].X, p)
+					}
+				}
+				if parallel {
+					parallels[l[0].Y] = true
+				} else {
+					meridians++
+				}
+			}
+			if meridians != 12 || len(parallels) != 2 || !parallels[tt.pole/3] || !parallels[tt.pole*2/3] {
+				t.Errorf("%d meridians and parallels at %v, want 12 and %v and %v", meridians, parallels, tt.pole/3, tt.pole*2/3)
+			}
+		})
+	}
+}
+
+func TestRenderGraticuleProjected(t *testing.T) {
+	g := Geometry{Xmin: 0, Xmax: 120, Ymin: 0, Ymax: 120, Longmin: -60, Longmax: 60, Latmin: -60, Latmax: 60}
+	c := NewConfig("line", "gray", 0.1)
+	c.Projection = AzimuthalEquidistant{Lat0: 90, Extent: 60}
+	var b strings.Builder
+	RenderGraticule(&b, g, 30, c)
+	segs := lineelements(t, b.String())
+	if len(segs) == 0 {
+		t.Fatal("no graticule drawn")
+	}
+	for _, s := range segs {
+		for _, p := range [][2]float64{{s[0], s[1]}, {s[2], s[3]}} {
+			if r := math.Hypot(p[0]-60, p[1]-60); r > 60+1e-6 {
+				t.Errorf("line end %v is %v from the pole, beyond the extent", p, r)
+			}
+		}
+	}
+}
+
+// meters is a projection to projected units, not degrees, with no graticule
+type meters struct{}
+
+func (meters) Project(lon, lat float64) (float64, float64) { return lon * 1000, lat * 1000 }
+
+// TestRenderGraticuleUnprojectedBounds steps degrees, not the projected
+// units of the bounds, for a Projection that is not a Graticuler
+func TestRenderGraticuleUnprojectedBounds(t *testing.T) {
+	g := Geometry{Xmin: 0, Xmax: 100, Ymin: 0, Ymax: 100, Longmin: -1500, Longmax: 1500, Latmin: -1500, Latmax: 1500}
+	c := NewConfig("line", "gray", 0.1)
+	c.Projection = meters{}
+	var b strings.Builder
+	RenderGraticule(&b, g, 1, c)
+	meridians, parallels := map[float64]bool{}, map[float64]bool{}
+	for _, s := range lineelements(t, b.String()) {
+		for _, v := range s {
+			if v < -1e-9 || v > 100+1e-9 {
+				t.Fatalf("line %v leaves the screen", s)
+			}
+		}
+		switch {
+		case s[0] == s[2]:
+			meridians[math.Round(s[0]*1e6)/1e6] = true
+		case s[1] == s[3]:
+			parallels[math.Round(s[1]*1e6)/1e6] = true
+		}
+	}
+	xs := slices.Sorted(maps.Keys(meridians))
+	want := []float64{100.0 / 6, 50, 500.0 / 6}
+	if len(xs) != len(want) || len(parallels) != 3 {
+		t.Fatalf("meridians at %v and %d parallels, want %v and 3", xs, len(parallels), want)
+	}
+	for i, x := range xs {
+		if math.Abs(x-want[i]) > 1e-5 {
+			t.Errorf("meridian at %g, want %g", x, want[i])
+		}
+	}
+}
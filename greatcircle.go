@@ -0,0 +1,59 @@
+package shpdeck
+
+import (
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// GreatCircle returns steps+1 points (longitude, latitude in degrees) along the
+// shortest great circle route on a sphere from the first point to the second,
+// including both ends. Identical or antipodal ends give the straight segment.
+// Longitudes are continuous along the route, so one that crosses the
+// antimeridian goes past ±180 rather than jumping to the other side.
+func GreatCircle(lon1, lat1, lon2, lat2 float64, steps int) []shp.Point {
+	steps = max(steps, 1)
+	rad := math.Pi / 180
+	ax, ay, az := unitvector(lon1*rad, lat1*rad)
+	bx, by, bz := unitvector(lon2*rad, lat2*rad)
+	d := math.Acos(clamp(ax*bx+ay*by+az*bz, -1, 1))
+	pts := make([]shp.Point, steps+1)
+	if sd := math.Sin(d); sd < 1e-12 {
+		for i := range pts {
+			t := float64(i) / float64(steps)
+			pts[i] = shp.Point{X: lon1 + (lon2-lon1)*t, Y: lat1 + (lat2-lat1)*t}
+		}
+		return pts
+	}
+	for i := range pts {
+		t := float64(i) / float64(steps)
+		fa := math.Sin((1-t)*d) / math.Sin(d)
+		fb := math.Sin(t*d) / math.Sin(d)
+		x, y, z := fa*ax+fb*bx, fa*ay+fb*by, fa*az+fb*bz
+		pts[i] = shp.Point{X: math.Atan2(y, x) / rad, Y: math.Atan2(z, math.Hypot(x, y)) / rad}
+	}
+	pts[0], pts[steps] = shp.Point{X: lon1, Y: lat1}, shp.Point{X: lon2, Y: lat2}
+	for i := 1; i <= steps; i++ {
+		pts[i].X = pts[i-1].X + wraplon(pts[i].X-pts[i-1].X)
+	}
+	return pts
+}
+
+// unitvector converts longitude and latitude in radians to a point on the unit sphere
+func unitvector(lon, lat float64) (x, y, z float64) {
+	return math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)
+}
+
+// densifyGreatCircle replaces each segment of a line with its great circle route
+func densifyGreatCircle(line []shp.Point, steps int) []shp.Point {
+	if len(line) < 2 {
+		return line
+	}
+	out := []shp.Point{line[0]}
+	for i := 1; i < len(line); i++ {
+		prev := out[len(out)-1]
+		seg := GreatCircle(prev.X, prev.Y, prev.X+wraplon(line[i].X-prev.X), line[i].Y, steps)
+		out = append(out, seg[1:]...)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package shpdeck
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestGreatCircleMidpoint(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lon1, lat1, lon2, lat2 float64
+		mid                    shp.Point
+	}{
+		{"equator", 0, 0, 90, 0, shp.Point{X: 45, Y: 0}},
+		{"meridian", 10, -30, 10, 50, shp.Point{X: 10, Y: 10}},
+		{"over the pole", -90, 45, 90, 45, shp.Point{X: 0, Y: 90}},
+		{"across the antimeridian", 170, 0, -170, 0, shp.Point{X: 180, Y: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pts := GreatCircle(tt.lon1, tt.lat1, tt.lon2, tt.lat2, 2)
+			got := pts[1]
+			if math.Abs(got.Y-tt.mid.Y) > 1e-9 || (tt.mid.Y != 90 && math.Abs(got.X-tt.mid.X) > 1e-9) {
+				t.Errorf("midpoint = %v, want %v", got, tt.mid)
+			}
+		})
+	}
+}
+
+func TestGreatCircleContinuous(t *testing.T) {
+	// Tokyo to San Francisco crosses the antimeridian
+	pts := GreatCircle(139.69, 35.69, -122.42, 37.77, 64)
+	for i := 1; i < len(pts); i++ {
+		if d := math.Abs(pts[i].X - pts[i-1].X); d > 10 {
+			t.Fatalf("longitude jumps %v between %v and %v", d, pts[i-1], pts[i])
+		}
+	}
+	if last := pts[len(pts)-1].X; math.Abs(last-(360-122.42)) > 1e-9 {
+		t.Errorf("route ends at longitude %v, want %v", last, 360-122.42)
+	}
+}
+
+// TestGreatCircleRenderSplits checks that a route drawn on a world map is
+// split at the antimeridian instead of streaking across the map
+func TestGreatCircleRenderSplits(t *testing.T) {
+	g := Geometry{Xmin: 0, Xmax: 360, Ymin: 0, Ymax: 180, Longmin: -180, Longmax: 180, Latmin: -90, Latmax: 90}
+	c := NewConfig("line", "red", 0.2)
+	c.GreatCircleSteps = 64
+	line := shp.NewPolyLine([][]shp.Point{{{X: 139.69, Y: 35.69}, {X: -122.42, Y: 37.77}}})
+	var b strings.Builder
+	PolylineCoords(&b, line, g, c)
+	segs := lineelements(t, b.String())
+	if len(segs) == 0 {
+		t.Fatal("no lines drawn")
+	}
+	for _, s := range segs {
+		if math.Abs(s[2]-s[0]) > 20 {
+			t.Errorf("line from x %v to %v streaks across the map", s[0], s[2])
+		}
+	}
+}
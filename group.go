@@ -0,0 +1,24 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+)
+
+// BeginGroup starts a named group of deck elements.
+// Groups may be nested; each BeginGroup must be paired with EndGroup.
+func BeginGroup(w io.Writer, name string) {
+	fmt.Fprintf(w, "<group name=\"%s\">\n", xmlesc(name))
+}
+
+// EndGroup closes the most recently opened group
+func EndGroup(w io.Writer) {
+	fmt.Fprintln(w, "</group>")
+}
+
+// Group wraps the markup written by render in a named group
+func Group(w io.Writer, name string, render func(io.Writer)) {
+	BeginGroup(w, name)
+	render(w)
+	EndGroup(w)
+}
@@ -0,0 +1,32 @@
+package shpdeck
+
+import (
+	"io"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderHighlight renders the features whose attributes satisfy pred in the
+// highlight style, on top of the rest in the base style, which would normally
+// be dimmed, for example with a low opacity. It returns how many features
+// were highlighted.
+func RenderHighlight(dest io.Writer, r *shp.Reader, g Geometry, base, highlight Config, pred func(attrs map[string]string) bool) (int, error) {
+	rest := &recordBuffer{reader: r}
+	picked := &recordBuffer{reader: r}
+	fields := r.Fields()
+	for r.Next() {
+		n, s := r.Shape()
+		if pred(recordattrs(r, n, fields)) {
+			picked.recs = append(picked.recs, record{row: n, shape: s})
+		} else {
+			rest.recs = append(rest.recs, record{row: n, shape: s})
+		}
+	}
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
+	if err := renderloop(dest, rest, g, base, nil, nil); err != nil {
+		return 0, err
+	}
+	return len(picked.recs), renderloop(dest, picked, g, highlight, nil, nil)
+}
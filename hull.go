@@ -0,0 +1,145 @@
+package shpdeck
+
+import (
+	"cmp"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// ConvexHull returns the convex hull of points, counter-clockwise from the
+// lowest-leftmost point, by Andrew's monotone chain. Duplicate points and
+// points on the hull's edges are left out; fewer than three distinct
+// points are returned as they are, deduplicated.
+func ConvexHull(points []shp.Point) []shp.Point {
+	pts := slices.Clone(points)
+	slices.SortFunc(pts, func(a, b shp.Point) int {
+		if c := cmp.Compare(a.X, b.X); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Y, b.Y)
+	})
+	pts = slices.Compact(pts)
+	if len(pts) < 3 {
+		return pts
+	}
+	hull := make([]shp.Point, 0, 2*len(pts))
+	// lower chain left to right, then upper chain right to left
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range pts {
+			for len(hull) >= start+2 && side(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1] // the last point starts the other chain
+		slices.Reverse(pts)
+	}
+	return hull
+}
+
+// RenderHull draws the convex hull of points as a polygon in the Config style
+func RenderHull(dest io.Writer, points []shp.Point, g Geometry, c Config) {
+	c = c.withscreen(g)
+	hull := ConvexHull(points)
+	if len(hull) < 3 {
+		c.skip("hull has fewer than 3 points")
+		return
+	}
+	mapring(dest, hull, g, c, true)
+}
+
+// circle is a center and radius
+type circle struct{ x, y, r float64 }
+
+func (c circle) contains(p shp.Point) bool {
+	return math.Hypot(p.X-c.x, p.Y-c.y) <= c.r*(1+1e-12)+1e-12
+}
+
+// diametercircle is the smallest circle through a and b
+func diametercircle(a, b shp.Point) circle {
+	return circle{(a.X + b.X) / 2, (a.Y + b.Y) / 2, math.Hypot(a.X-b.X, a.Y-b.Y) / 2}
+}
+
+// circumcircle is the circle through a, b and c; for collinear points it is
+// the circle on the farthest pair
+func circumcircle(a, b, c shp.Point) circle {
+	bx, by := b.X-a.X, b.Y-a.Y
+	cx, cy := c.X-a.X, c.Y-a.Y
+	d := 2 * (bx*cy - by*cx)
+	if d == 0 {
+		e := diametercircle(a, b)
+		for _, f := range []circle{diametercircle(a, c), diametercircle(b, c)} {
+			if f.r > e.r {
+				e = f
+			}
+		}
+		return e
+	}
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	ux, uy := (cy*b2-by*c2)/d, (bx*c2-cx*b2)/d
+	return circle{a.X + ux, a.Y + uy, math.Hypot(ux, uy)}
+}
+
+// MinEnclosingCircle returns the center and radius of the smallest circle
+// holding every point, by Welzl's algorithm in its iterative form, over the
+// points in a shuffled (but always the same) order, which gives expected
+// linear time. No points give a zero circle at the origin, one point a
+// circle of radius 0 on it, and two the circle on which they are opposite.
+func MinEnclosingCircle(points []shp.Point) (cx, cy, r float64) {
+	switch len(points) {
+	case 0:
+		return 0, 0, 0
+	case 1:
+		return points[0].X, points[0].Y, 0
+	}
+	pts := slices.Clone(points)
+	rng := rand.New(rand.NewPCG(1, 1))
+	rng.Shuffle(len(pts), func(i, j int) { pts[i], pts[j] = pts[j], pts[i] })
+	c := circle{pts[0].X, pts[0].Y, 0}
+	for i := 1; i < len(pts); i++ {
+		if c.contains(pts[i]) {
+			continue
+		}
+		// pts[i] is on the boundary of the circle of pts[:i+1]
+		c = circle{pts[i].X, pts[i].Y, 0}
+		for j := range i {
+			if c.contains(pts[j]) {
+				continue
+			}
+			c = diametercircle(pts[i], pts[j])
+			for k := range j {
+				if !c.contains(pts[k]) {
+					c = circumcircle(pts[i], pts[j], pts[k])
+				}
+			}
+		}
+	}
+	return c.x, c.y, c.r
+}
+
+// enclosingsteps is the number of sides of the polygon that draws a circle
+const enclosingsteps = 64
+
+// RenderEnclosingCircle draws the minimum enclosing circle of points as a
+// polygon in the Config style, in geographic coordinates, so that it is
+// projected and clipped like the features it encloses
+func RenderEnclosingCircle(dest io.Writer, points []shp.Point, g Geometry, c Config) {
+	c = c.withscreen(g)
+	if len(points) == 0 {
+		c.skip("no points to enclose")
+		return
+	}
+	cx, cy, r := MinEnclosingCircle(points)
+	ring := make([]shp.Point, enclosingsteps+1)
+	for i := range enclosingsteps {
+		a := 2 * math.Pi * float64(i) / enclosingsteps
+		ring[i] = shp.Point{X: cx + r*math.Cos(a), Y: cy + r*math.Sin(a)}
+	}
+	ring[enclosingsteps] = ring[0]
+	mapring(dest, ring, g, c, true)
+}
@@ -0,0 +1,86 @@
+package shpdeck
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestConvexHull(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []shp.Point
+		want   []shp.Point
+	}{
+		{
+			"square with inner, edge and duplicate points",
+			[]shp.Point{{X: 2, Y: 2}, {X: 0, Y: 4}, {X: 4, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 0}, {X: 4, Y: 2}, {X: 1, Y: 3}},
+			[]shp.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}},
+		},
+		{
+			"triangle",
+			[]shp.Point{{X: 5, Y: 5}, {X: 0, Y: 0}, {X: 10, Y: 0}, {X: 5, Y: 1}},
+			[]shp.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 5, Y: 5}},
+		},
+		{
+			"collinear",
+			[]shp.Point{{X: 1, Y: 1}, {X: 3, Y: 3}, {X: 0, Y: 0}, {X: 2, Y: 2}},
+			[]shp.Point{{X: 0, Y: 0}, {X: 3, Y: 3}},
+		},
+		{"one point repeated", []shp.Point{{X: 1, Y: 2}, {X: 1, Y: 2}}, []shp.Point{{X: 1, Y: 2}}},
+		{"empty", nil, []shp.Point{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvexHull(tt.points); !slices.Equal(got, tt.want) {
+				t.Errorf("ConvexHull = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinEnclosingCircle(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []shp.Point
+		cx, cy, r float64
+	}{
+		{"none", nil, 0, 0, 0},
+		{"one", []shp.Point{{X: 3, Y: 4}}, 3, 4, 0},
+		{"two", []shp.Point{{X: 0, Y: 0}, {X: 4, Y: 0}}, 2, 0, 2},
+		{"duplicates", []shp.Point{{X: 1, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 1}}, 1, 1, 0},
+		{"collinear", []shp.Point{{X: 1, Y: 0}, {X: 0, Y: 0}, {X: 5, Y: 0}, {X: 2, Y: 0}}, 2.5, 0, 2.5},
+		{"right triangle", []shp.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 3}}, 2, 1.5, 2.5},
+		{"obtuse triangle", []shp.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 5, Y: 1}}, 5, 0, 5},
+		{"square and center", []shp.Point{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 0, Y: 2}}, 1, 1, math.Sqrt2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cx, cy, r := MinEnclosingCircle(tt.points)
+			if math.Abs(cx-tt.cx) > 1e-9 || math.Abs(cy-tt.cy) > 1e-9 || math.Abs(r-tt.r) > 1e-9 {
+				t.Errorf("MinEnclosingCircle = (%v, %v) r %v, want (%v, %v) r %v", cx, cy, r, tt.cx, tt.cy, tt.r)
+			}
+		})
+	}
+}
+
+// TestMinEnclosingCircleContains checks that a circle around points on a
+// spiral holds them all, and is the circle of their convex hull
+func TestMinEnclosingCircleContains(t *testing.T) {
+	pts := make([]shp.Point, 500)
+	for i := range pts {
+		a, d := float64(i)*2.399963, math.Sqrt(float64(i))
+		pts[i] = shp.Point{X: 7 + d*math.Cos(a), Y: -3 + d*1.7*math.Sin(a)}
+	}
+	cx, cy, r := MinEnclosingCircle(pts)
+	for _, p := range pts {
+		if d := math.Hypot(p.X-cx, p.Y-cy); d > r*(1+1e-9) {
+			t.Fatalf("%v is %v from the center, outside radius %v", p, d, r)
+		}
+	}
+	if hx, hy, hr := MinEnclosingCircle(ConvexHull(pts)); math.Abs(hx-cx) > 1e-9 || math.Abs(hy-cy) > 1e-9 || math.Abs(hr-r) > 1e-9 {
+		t.Errorf("circle of the hull (%v, %v) r %v, of the points (%v, %v) r %v", hx, hy, hr, cx, cy, r)
+	}
+}
@@ -0,0 +1,83 @@
+package shpdeck
+
+import "math"
+
+// insetmiter limits how far a vertex moves, as a multiple of the inset,
+// so that sharp corners do not shoot out
+const insetmiter = 4
+
+// inset offsets a ring inward by d screen units. Each edge moves d along its
+// inward normal, and each vertex goes where the moved edges on either side
+// of it meet (a miter join), limited to insetmiter times d at sharp corners.
+// This is exact for convex rings with d smaller than their inradius. On
+// concave rings, an inset wider than a narrow neck or a reflex corner's
+// neighboring edges makes the offset ring cross itself, which is left as is.
+// A ring whose winding reverses, or whose edges mostly run backward, has
+// shrunk past nothing, and inset returns nothing for it.
+func inset(x, y []float64, d float64) ([]float64, []float64) {
+	n := len(x)
+	closed := n > 1 && x[0] == x[n-1] && y[0] == y[n-1]
+	if closed {
+		n--
+	}
+	if n < 3 {
+		return x, y
+	}
+	area := signedarea(x[:n], y[:n])
+	if area == 0 {
+		return nil, nil
+	}
+	// for a counterclockwise ring the inside is to the left of each edge
+	side := 1.0
+	if area < 0 {
+		side = -1
+	}
+	normal := func(i, j int) (float64, float64) {
+		dx, dy := x[j]-x[i], y[j]-y[i]
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return 0, 0
+		}
+		return -dy / l * side, dx / l * side
+	}
+	ox, oy := make([]float64, n, n+1), make([]float64, n, n+1)
+	for i := range n {
+		prev, next := (i+n-1)%n, (i+1)%n
+		n1x, n1y := normal(prev, i)
+		n2x, n2y := normal(i, next)
+		// the miter direction bisects the normals, scaled to reach both offset edges
+		mx, my := n1x+n2x, n1y+n2y
+		dot := 1 + n1x*n2x + n1y*n2y
+		if dot < 1e-9 {
+			mx, my, dot = n2x, n2y, 1 // a reversal: move along one normal
+		}
+		k := min(d/math.Sqrt(dot/2), insetmiter*d)
+		if l := math.Hypot(mx, my); l > 0 {
+			mx, my = mx/l, my/l
+		}
+		ox[i], oy[i] = x[i]+mx*k, y[i]+my*k
+	}
+	// edges that run backward have been crossed by the opposite side
+	along := 0.0
+	for i := range n {
+		j := (i + 1) % n
+		along += (x[j]-x[i])*(ox[j]-ox[i]) + (y[j]-y[i])*(oy[j]-oy[i])
+	}
+	if a := signedarea(ox, oy); along <= 0 || a == 0 || (a > 0) != (area > 0) {
+		return nil, nil
+	}
+	if closed {
+		ox, oy = append(ox, ox[0]), append(oy, oy[0])
+	}
+	return ox, oy
+}
+
+// signedarea is the shoelace area of a ring, positive counterclockwise
+func signedarea(x, y []float64) float64 {
+	a := 0.0
+	for i := range x {
+		j := (i + 1) % len(x)
+		a += x[i]*y[j] - x[j]*y[i]
+	}
+	return a / 2
+}
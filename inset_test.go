@@ -0,0 +1,46 @@
+package shpdeck
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestInset moves every edge of a ring inward, whichever way it winds,
+// limits the miter at sharp corners, and drops rings it shrinks past nothing
+func TestInset(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         []float64
+		d            float64
+		wantx, wanty []float64
+	}{
+		{"counterclockwise", []float64{0, 10, 10, 0}, []float64{0, 0, 10, 10}, 1, []float64{1, 9, 9, 1}, []float64{1, 1, 9, 9}},
+		{"clockwise and closed", []float64{0, 0, 10, 10, 0}, []float64{0, 10, 10, 0, 0}, 1, []float64{1, 1, 9, 9, 1}, []float64{1, 9, 9, 1, 1}},
+		{"shrunk past nothing", []float64{0, 10, 10, 0}, []float64{0, 0, 10, 10}, 6, nil, nil},
+		{"flat", []float64{0, 5, 10}, []float64{0, 0, 0}, 1, nil, nil},
+		{"too few points", []float64{0, 10}, []float64{0, 0}, 1, []float64{0, 10}, []float64{0, 0}},
+	}
+	round := func(v []float64) []float64 {
+		for i := range v {
+			v[i] = math.Round(v[i]*1e9) / 1e9
+		}
+		return v
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := inset(tt.x, tt.y, tt.d)
+			if !slices.Equal(round(x), tt.wantx) || !slices.Equal(round(y), tt.wanty) {
+				t.Errorf("inset to %v %v, want %v %v", x, y, tt.wantx, tt.wanty)
+			}
+		})
+	}
+	// the tip of a needle moves no more than insetmiter times the inset
+	x, y := inset([]float64{0, 100, 0}, []float64{0, 1, 2}, 0.1)
+	if x == nil {
+		t.Fatal("the needle vanished")
+	}
+	if moved := math.Hypot(x[1]-100, y[1]-1); moved > insetmiter*0.1+1e-9 {
+		t.Errorf("the tip moved %g, more than the miter limit", moved)
+	}
+}
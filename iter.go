@@ -0,0 +1,40 @@
+package shpdeck
+
+import (
+	"iter"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Shapes iterates over the records of r, yielding each shape with its
+// attributes by field name:
+//
+//	for shape, attrs := range shpdeck.Shapes(r) {
+//		...
+//	}
+//	if err := r.Err(); err != nil {
+//		...
+//	}
+//
+// Iteration advances r, and stops early if the loop breaks; as with
+// r.Next, a read error ends it, and is reported by r.Err().
+func Shapes(r *shp.Reader) iter.Seq2[shp.Shape, map[string]string] {
+	return func(yield func(shp.Shape, map[string]string) bool) {
+		fields := r.Fields()
+		for r.Next() {
+			n, s := r.Shape()
+			if !yield(s, recordattrs(r, n, fields)) {
+				return
+			}
+		}
+	}
+}
+
+// recordattrs reads the attributes of record n by field name
+func recordattrs(r *shp.Reader, n int, fields []shp.Field) map[string]string {
+	attrs := make(map[string]string, len(fields))
+	for i, f := range fields {
+		attrs[f.String()] = r.ReadAttribute(n, i)
+	}
+	return attrs
+}
@@ -0,0 +1,44 @@
+package shpdeck
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestShapes yields each record with its attributes by name, in order, and
+// leaves the reader after the last record yielded when the loop breaks
+func TestShapes(t *testing.T) {
+	shapes := []shp.Shape{square(0, 0, 1), square(2, 0, 1), square(4, 0, 1)}
+	fields := []shp.Field{shp.StringField("NAME", 8), shp.StringField("POP", 6)}
+	rows := [][]any{{"alpha", "10"}, {"bravo", "20"}, {"charlie", "30"}}
+	filename := writeShapefile(t, shp.POLYGON, shapes, fields, rows)
+
+	want := []map[string]string{{"NAME": "alpha", "POP": "10"}, {"NAME": "bravo", "POP": "20"}, {"NAME": "charlie", "POP": "30"}}
+	r := openShapefile(t, filename)
+	i := 0
+	for s, attrs := range Shapes(r) {
+		if s.BBox().MinX != float64(2*i) {
+			t.Errorf("shape %d at x %g", i, s.BBox().MinX)
+		}
+		if !maps.Equal(attrs, want[i]) {
+			t.Errorf("record %d attributes %q, want %q", i, attrs, want[i])
+		}
+		i++
+	}
+	if i != len(shapes) || r.Err() != nil {
+		t.Errorf("%d records, error %v", i, r.Err())
+	}
+
+	r = openShapefile(t, filename)
+	for range Shapes(r) {
+		break
+	}
+	if !r.Next() {
+		t.Fatal("no records after the break")
+	}
+	if n, _ := r.Shape(); n != 1 {
+		t.Errorf("record %d follows the break, want 1", n)
+	}
+}
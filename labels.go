@@ -0,0 +1,179 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Leader is the style of the lines joining displaced labels to their features
+type Leader struct {
+	Color string  // line color, with optional opacity as "name:op"; empty draws no leaders
+	Width float64 // line width; zero uses 0.1
+}
+
+// labelbox is the screen extent of a placed label
+type labelbox struct {
+	x0, y0, x1, y1 float64
+}
+
+func (b labelbox) overlaps(o labelbox) bool {
+	return b.x0 < o.x1 && o.x0 < b.x1 && b.y0 < o.y1 && o.y0 < b.y1
+}
+
+// textwidth estimates the width of s at size
+func textwidth(s string, size float64) float64 {
+	return 0.6 * size * float64(utf8.RuneCountInString(s))
+}
+
+// labelanchor is the screen point a label for s belongs to:
+// the point itself, the first of a multipoint, or the middle of the bounding box
+func labelanchor(s shp.Shape, g Geometry, c Config) (float64, float64, bool) {
+	switch v := s.(type) {
+	case *shp.Point:
+		x, y := c.mappoint(*v, g)
+		return x, y, true
+	case *shp.MultiPoint:
+		if len(v.Points) == 0 {
+			return 0, 0, false
+		}
+		x, y := c.mappoint(v.Points[0], g)
+		return x, y, true
+	case *shp.Polygon, *shp.PolyLine:
+		b := s.BBox()
+		x, y := c.mappoint(shp.Point{X: (b.MinX + b.MaxX) / 2, Y: (b.MinY + b.MaxY) / 2}, g)
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+// featurewidth is the mapped width of a feature's bounding box, or zero for points
+func featurewidth(s shp.Shape, g Geometry, c Config) float64 {
+	switch s.(type) {
+	case *shp.Polygon, *shp.PolyLine:
+		b := s.BBox()
+		x0, _ := c.mappoint(shp.Point{X: b.MinX, Y: b.MinY}, g)
+		x1, _ := c.mappoint(shp.Point{X: b.MaxX, Y: b.MaxY}, g)
+		return math.Abs(x1 - x0)
+	}
+	return 0
+}
+
+// abbreviate shortens a label wider than width at size: to its entry in
+// Config.Abbreviations, if there is one, otherwise by truncating it with an ellipsis
+func abbreviate(label string, width, size float64, c Config) string {
+	if textwidth(label, size) <= width {
+		return label
+	}
+	if a, ok := c.Abbreviations[label]; ok {
+		return a
+	}
+	return truncate(label, max(int(width/(0.6*size)), 2))
+}
+
+// truncate shortens s to n characters, the last an ellipsis
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:max(n-1, 1)])) + "…"
+}
+
+// labeler places labels so that they do not overlap
+type labeler struct {
+	ts     TextStyle
+	placed []labelbox
+}
+
+// place tries positions right, left, above and below (x, y), at increasing
+// distance, and writes the label at the first that is free. Labels moved
+// beyond the nearest positions get a leader when Config.Leader is set.
+// It reports whether the label was placed.
+func (l *labeler) place(w io.Writer, x, y float64, s string, c Config) bool {
+	size := l.ts.Size
+	wd, ht := textwidth(s, size), size
+	for k := 1; k <= 3; k++ {
+		gap := size / 2 * float64(k)
+		for _, d := range [][2]float64{
+			{gap + wd/2, 0}, {-gap - wd/2, 0}, {0, gap + ht/2}, {0, -gap - ht/2},
+		} {
+			cx, cy := x+d[0], y+d[1]
+			b := labelbox{cx - wd/2, cy - ht/2, cx + wd/2, cy + ht/2}
+			if l.collides(b) {
+				continue
+			}
+			l.placed = append(l.placed, b)
+			if k > 1 && c.Leader.Color != "" {
+				lw := c.Leader.Width
+				if lw <= 0 {
+					lw = 0.1
+				}
+				fill, op := colorattr(c.Leader.Color)
+				c.line(w, x, y, clamp(x, b.x0, b.x1), clamp(y, b.y0, b.y1), fill, op, lw)
+			}
+			tx := cx
+			switch l.ts.Align {
+			case "left":
+				tx = b.x0
+			case "right":
+				tx = b.x1
+			}
+			c.text(w, l.ts, tx, cy-size/3, s, 0)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *labeler) collides(b labelbox) bool {
+	for _, p := range l.placed {
+		if b.overlaps(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderLabels writes the value of field for each record as a label by its
+// feature, moving labels to avoid overlapping those already placed.
+// Labels that cannot be placed are skipped.
+func RenderLabels(dest io.Writer, r *shp.Reader, g Geometry, field string, c Config) error {
+	c = c.withscreen(g)
+	fi := fieldIndex(r, field)
+	if fi < 0 {
+		return fmt.Errorf("labels: no field named %q", field)
+	}
+	l := &labeler{ts: c.textstyle(TextStyle{})}
+	deleted := readerdeleted(r)
+	for r.Next() {
+		n, s := r.Shape()
+		fc := c
+		fc.record = n
+		if isdeleted(deleted, n) {
+			fc.skip("deleted in the DBF")
+			continue
+		}
+		label := strings.TrimSpace(c.attr(r, n, fi))
+		if label == "" {
+			continue
+		}
+		x, y, ok := labelanchor(s, g, fc)
+		if !ok {
+			continue
+		}
+		if c.AbbreviateLabels {
+			if w := featurewidth(s, g, fc); w > 0 {
+				label = abbreviate(label, w, l.ts.Size, fc)
+			}
+		}
+		if !l.place(dest, x, y, label, fc) {
+			fc.skip("label overlaps")
+		}
+	}
+	return r.Err()
+}
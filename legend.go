@@ -0,0 +1,57 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+	"slices"
+)
+
+// ProportionalSize scales v within [vmin, vmax] to a symbol size in
+// [minSize, maxSize] by square root, so that symbol area is proportional to value
+func ProportionalSize(v, vmin, vmax, minSize, maxSize float64) float64 {
+	if vmax <= vmin {
+		return maxSize
+	}
+	t := math.Sqrt(clamp((v-vmin)/(vmax-vmin), 0, 1))
+	return minSize + (maxSize-minSize)*t
+}
+
+// RenderProportionalLegend draws nested circles, one per value, sharing a
+// baseline at (x, y), each labeled with its value to the right. Sizes use the
+// same square root scaling as ProportionalSize over the range of values.
+func RenderProportionalLegend(dest io.Writer, values []float64, minSize, maxSize float64, x, y float64, c Config) {
+	if len(values) == 0 {
+		return
+	}
+	vs := slices.Clone(values)
+	slices.Sort(vs)
+	vmin, vmax := vs[0], vs[len(vs)-1]
+	fill, op := colorattr(c.color)
+	// largest first, so the smaller circles sit on top
+	for i := len(vs) - 1; i >= 0; i-- {
+		d := ProportionalSize(vs[i], vmin, vmax, minSize, maxSize)
+		c.dot(dest, x, y+d/2, fill, op, d)
+	}
+	ts := c.textstyle(TextStyle{Color: c.color})
+	tx := x + maxSize/2 + ts.Size
+	for i := len(vs) - 1; i >= 0; i-- {
+		d := ProportionalSize(vs[i], vmin, vmax, minSize, maxSize)
+		c.line(dest, x, y+d, tx-ts.Size/2, y+d, fill, op, 0.1)
+		c.text(dest, ts, tx, y+d-ts.Size/3, c.Locale.Format(vs[i], -1), 0)
+	}
+}
+
+// RenderSwatches draws one square of the given size per class, stacked upward
+// from (x, y), in the class colors of a Classed choropleth with these breaks
+// (see Classify), each labeled with its range in the Config locale
+func RenderSwatches(dest io.Writer, breaks []float64, pal Palette, x, y, size float64, c Config) {
+	n := len(breaks) - 1
+	ts := c.textstyle(TextStyle{})
+	for i := range n {
+		fill, op := colorattr(ClassColor(i, n, pal))
+		cy := y + size*(float64(i)+0.5)
+		c.rect(dest, x+size/2, cy, size, size, fill, op)
+		label := c.Locale.Format(breaks[i], -1) + " – " + c.Locale.Format(breaks[i+1], -1)
+		c.text(dest, ts, x+size+ts.Size/2, cy-ts.Size/3, label, 0)
+	}
+}
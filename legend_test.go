@@ -0,0 +1,85 @@
+package shpdeck
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestLegendRects draws swatches, the bivariate legend and the background
+// as rect elements of the Config format, which an SVG reader accepts
+func TestLegendRects(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(io.Writer, Config)
+		rects  int
+	}{
+		{"swatches", func(w io.Writer, c Config) {
+			RenderSwatches(w, []float64{0, 10, 20}, Palette{"white", "black"}, 10, 10, 4, c)
+		}, 2},
+		{"bivariate legend", func(w io.Writer, c Config) {
+			RenderBivariateLegend(w, [][]string{{"#e8e8e8", "#5ac8c8"}, {"#be64ac", "#3b4994"}}, 10, 10, 4, "income", "age", c)
+		}, 4},
+		{"background", func(w io.Writer, c Config) { RenderBackground(w, unit, c) }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "lightblue", 0)
+			var deck strings.Builder
+			tt.render(&deck, c)
+			if n := strings.Count(deck.String(), "<rect xp="); n != tt.rects {
+				t.Errorf("deck: %d rects, want %d:\n%s", n, tt.rects, deck.String())
+			}
+
+			c.Format = SVG
+			var svg strings.Builder
+			tt.render(&svg, c)
+			if strings.Contains(svg.String(), " xp=") {
+				t.Errorf("SVG output has deck attributes:\n%s", svg.String())
+			}
+			d := xml.NewDecoder(strings.NewReader("<svg>" + svg.String() + "</svg>"))
+			rects := 0
+			for {
+				tok, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%v in\n%s", err, svg.String())
+				}
+				if e, ok := tok.(xml.StartElement); ok && e.Name.Local == "rect" {
+					rects++
+				}
+			}
+			if rects != tt.rects {
+				t.Errorf("SVG: %d rects, want %d", rects, tt.rects)
+			}
+		})
+	}
+}
+
+func TestRenderBackground(t *testing.T) {
+	tests := []struct {
+		name   string
+		g      Geometry
+		format Format
+		rel    bool
+		want   string
+	}{
+		{"deck", Geometry{Xmin: 10, Xmax: 90, Ymin: 20, Ymax: 60}, Deck, false, `<rect xp="50.00000" yp="40.00000" wp="80.00000" hp="40.00000" color="navy" opacity="50"/>`},
+		{"relative", Geometry{Xmin: 10, Xmax: 90, Ymin: 20, Ymax: 60}, Deck, true, `<rect xp="50.00000" yp="50.00000" wp="100.00000" hp="100.00000" color="navy" opacity="50"/>`},
+		{"SVG north up", Geometry{Xmin: 0, Xmax: 960, Ymin: 540, Ymax: 0}, SVG, false, `<rect x="0.00000" y="0.00000" width="960.00000" height="540.00000" fill="navy" fill-opacity="0.5"/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "navy:50", 0)
+			c.Format, c.Relative = tt.format, tt.rel
+			var b strings.Builder
+			RenderBackground(&b, tt.g, c)
+			if got := strings.TrimSpace(b.String()); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderLineLabel writes a label along the longest part of a polyline, centered
+// on its midpoint, one character at a time, each rotated to the local direction
+// of the line. Lines running right to left are followed backwards so the text
+// is never upside down. Character spacing is estimated from the text size.
+func RenderLineLabel(dest io.Writer, poly *shp.PolyLine, g Geometry, label string, c Config) {
+	c = c.withscreen(g)
+	if poly.NumParts == 0 || label == "" {
+		return
+	}
+	// longest part
+	var x, y []float64
+	for _, part := range partpoints(poly.Points, poly.Parts, poly.NumPoints) {
+		if len(part) > len(x) {
+			x, y = x[:0], y[:0]
+			for _, p := range part {
+				px, py := c.mappoint(p, g)
+				x, y = append(x, px), append(y, py)
+			}
+		}
+	}
+	if len(x) < 2 {
+		return
+	}
+	if x[len(x)-1] < x[0] {
+		slices.Reverse(x)
+		slices.Reverse(y)
+	}
+	// cumulative distance along the line
+	dist := make([]float64, len(x))
+	for i := 1; i < len(x); i++ {
+		dist[i] = dist[i-1] + math.Hypot(x[i]-x[i-1], y[i]-y[i-1])
+	}
+	total := dist[len(dist)-1]
+	runes := []rune(label)
+	ts := c.textstyle(TextStyle{Color: c.color, Align: "center"})
+	advance := ts.Size * 0.6
+	s := math.Max(0, total/2-float64(len(runes))*advance/2)
+	for _, r := range runes {
+		at := s + advance/2
+		i := sort.SearchFloat64s(dist, at)
+		i = min(max(i, 1), len(dist)-1)
+		seg := dist[i] - dist[i-1]
+		t := 0.0
+		if seg > 0 {
+			t = (at - dist[i-1]) / seg
+		}
+		px := x[i-1] + (x[i]-x[i-1])*t
+		py := y[i-1] + (y[i]-y[i-1])*t
+		// text turns counter-clockwise as seen, and the y of the output
+		// points up in deck and down in SVG, whichever way g maps
+		dy := y[i] - y[i-1]
+		if c.Format == SVG {
+			dy = -dy
+		}
+		angle := math.Atan2(dy, x[i]-x[i-1]) * 180 / math.Pi
+		c.text(dest, ts, px, py, string(r), angle)
+		s += advance
+	}
+}
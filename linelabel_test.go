@@ -0,0 +1,60 @@
+package shpdeck
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestLineLabelRotation turns the characters of a label along a line
+// rising to the right, as seen, in both formats and screen orientations
+func TestLineLabelRotation(t *testing.T) {
+	line := shp.NewPolyLine([][]shp.Point{{{X: 1, Y: 1}, {X: 9, Y: 9}}})
+	flipped := unit
+	flipped.Ymin, flipped.Ymax = 100, 0
+	tests := []struct {
+		name   string
+		format Format
+		g      Geometry
+	}{
+		{"deck", Deck, unit},
+		{"deck flipped", Deck, flipped},
+		{"SVG north up", SVG, flipped},
+		{"SVG south up", SVG, unit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("line", "black", 0)
+			c.Format = tt.format
+			var b strings.Builder
+			RenderLineLabel(&b, line, tt.g, "ab", c)
+			// the direction of the line in the output's own axes
+			x0, y0 := c.mappoint(shp.Point{X: 1, Y: 1}, tt.g)
+			x1, y1 := c.mappoint(shp.Point{X: 9, Y: 9}, tt.g)
+			dx, dy := x1-x0, y1-y0
+			chars := 0
+			for _, l := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+				var r float64
+				if tt.format == SVG {
+					_, rest, _ := strings.Cut(l, `rotate(`)
+					fmt.Sscanf(rest, "%g", &r)
+				} else {
+					_, rest, _ := strings.Cut(l, `rotation="`)
+					fmt.Sscanf(rest, "%g", &r)
+				}
+				// the baseline, (1, 0) turned by r in the output's axes
+				a := r * math.Pi / 180
+				if cross := math.Cos(a)*dy - math.Sin(a)*dx; math.Abs(cross) > 1e-6*math.Hypot(dx, dy) || math.Cos(a)*dx+math.Sin(a)*dy <= 0 {
+					t.Errorf("%s: the baseline does not follow the line (%v, %v)", l, dx, dy)
+				}
+				chars++
+			}
+			if chars != 2 {
+				t.Errorf("%d characters, want 2:\n%s", chars, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package shpdeck
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Locale describes how numbers in label text are written.
+// Coordinates in markup always use "." regardless of Locale.
+type Locale struct {
+	Decimal string // decimal separator
+	Group   string // thousands separator, empty for none
+}
+
+// common locales
+var (
+	LocaleC  = Locale{Decimal: "."}
+	LocaleUS = Locale{Decimal: ".", Group: ","}
+	LocaleDE = Locale{Decimal: ",", Group: "."}
+	LocaleFR = Locale{Decimal: ",", Group: " "}
+)
+
+// Format writes a number with prec decimal places using the locale's separators
+func (l Locale) Format(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasfrac := strings.Cut(s, ".")
+	if l.Group != "" && len(whole) > 3 {
+		var b strings.Builder
+		lead := len(whole) % 3
+		if lead > 0 {
+			b.WriteString(whole[:lead])
+		}
+		for i := lead; i < len(whole); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.Group)
+			}
+			b.WriteString(whole[i : i+3])
+		}
+		whole = b.String()
+	}
+	dec := l.Decimal
+	if dec == "" {
+		dec = "."
+	}
+	if hasfrac {
+		return sign + whole + dec + frac
+	}
+	return sign + whole
+}
@@ -0,0 +1,25 @@
+package shpdeck
+
+import "testing"
+
+func TestLocaleFormat(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		v      float64
+		prec   int
+		want   string
+	}{
+		{LocaleC, 1234567.891, 2, "1234567.89"},
+		{LocaleUS, 1234567.891, 2, "1,234,567.89"},
+		{LocaleDE, 1234567.891, 2, "1.234.567,89"},
+		{LocaleFR, -1234.5, 1, "-1\u202f234,5"},
+		{LocaleUS, 999, 0, "999"},
+		{LocaleUS, -100000, 0, "-100,000"},
+		{Locale{}, 3.25, 2, "3.25"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.Format(tt.v, tt.prec); got != tt.want {
+			t.Errorf("%+v.Format(%v, %d) = %q, want %q", tt.locale, tt.v, tt.prec, got, tt.want)
+		}
+	}
+}
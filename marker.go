@@ -0,0 +1,38 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+)
+
+// marker shape names for Config.Marker
+const (
+	MarkerDot      = ""         // a circle (the default)
+	MarkerTriangle = "triangle" // an equilateral triangle pointing up, or along the bearing
+	MarkerArrow    = "arrow"    // a notched arrowhead pointing up, or along the bearing
+)
+
+// markershapes are the outlines of the markers, within a unit circle, pointing north
+var markershapes = map[string][][2]float64{
+	MarkerTriangle: {{0, 1}, {0.866, -0.5}, {-0.866, -0.5}},
+	MarkerArrow:    {{0, 1}, {0.6, -0.8}, {0, -0.4}, {-0.6, -0.8}},
+}
+
+// marker draws the Config marker of the Config size at (x, y), rotated
+// clockwise by the record's bearing in degrees
+func (c Config) marker(w io.Writer, x, y float64) {
+	shape, ok := markershapes[c.Marker]
+	if !ok {
+		fill, op := colorattr(c.color)
+		c.dot(w, x, y, fill, op, c.shapesize)
+		return
+	}
+	r := c.shapesize / 2
+	sin, cos := math.Sincos(c.bearing * radians)
+	xs, ys := make([]float64, len(shape)), make([]float64, len(shape))
+	for i, p := range shape {
+		xs[i] = x + r*(p[0]*cos+p[1]*sin)
+		ys[i] = y + r*(p[1]*cos-p[0]*sin)
+	}
+	mapshape(w, xs, ys, "polygon", c)
+}
@@ -0,0 +1,235 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Triangulate splits a simple polygon ring, convex or not, into triangles by
+// ear clipping. The triangles can be used as a Config.ClipMask.
+func Triangulate(ring []shp.Point) [][]shp.Point {
+	pts := slices.Clone(ring)
+	if n := len(pts); n > 1 && pts[0] == pts[n-1] {
+		pts = pts[:n-1]
+	}
+	if signedArea(pts) < 0 {
+		slices.Reverse(pts)
+	}
+	var tris [][]shp.Point
+	for len(pts) > 3 {
+		clipped := false
+		for i := range pts {
+			a, b, c := pts[(i+len(pts)-1)%len(pts)], pts[i], pts[(i+1)%len(pts)]
+			s := side(a, b, c)
+			if s < 0 {
+				continue // reflex vertex
+			}
+			if s == 0 || isear(a, b, c, pts) {
+				if s > 0 {
+					tris = append(tris, []shp.Point{a, b, c})
+				}
+				pts = slices.Delete(pts, i, i+1)
+				clipped = true
+				break
+			}
+		}
+		if !clipped {
+			break // not a simple polygon
+		}
+	}
+	if len(pts) == 3 && side(pts[0], pts[1], pts[2]) > 0 {
+		tris = append(tris, pts)
+	}
+	return tris
+}
+
+// isear reports whether no other vertex lies inside the triangle a, b, c or on
+// its diagonal c-a: a vertex on the diagonal means it leaves the polygon
+func isear(a, b, c shp.Point, pts []shp.Point) bool {
+	for _, p := range pts {
+		if p == a || p == b || p == c {
+			continue
+		}
+		if side(a, b, p) >= 0 && side(b, c, p) >= 0 && side(c, a, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// polygonmask triangulates a polygon, holes excluded, for a Config.ClipMask
+func polygonmask(poly *shp.Polygon) [][]shp.Point {
+	var mask [][]shp.Point
+	for _, group := range ringgroups(partpoints(poly.Points, poly.Parts, poly.NumPoints)) {
+		mask = append(mask, Triangulate(bridgeholes(group))...)
+	}
+	return mask
+}
+
+// MaskFromFeature builds a clip mask from the polygons of r whose field equals value,
+// leaving out deleted records. Holes in the polygons are kept out of the mask.
+func MaskFromFeature(r *shp.Reader, field, value string) ([][]shp.Point, error) {
+	fi := fieldIndex(r, field)
+	if fi < 0 {
+		return nil, fmt.Errorf("mask: no field named %q", field)
+	}
+	var mask [][]shp.Point
+	deleted := readerdeleted(r)
+	for r.Next() {
+		n, s := r.Shape()
+		poly, ok := s.(*shp.Polygon)
+		if !ok || isdeleted(deleted, n) || r.ReadAttribute(n, fi) != value {
+			continue
+		}
+		mask = append(mask, polygonmask(poly)...)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("mask: no polygon with %s = %q", field, value)
+	}
+	return mask, nil
+}
+
+// RenderMasked renders the detail layer clipped to the feature of the mask
+// layer whose field equals value, for example full detail of the counties
+// inside one selected state.
+func RenderMasked(dest io.Writer, mask *shp.Reader, field, value string, detail *shp.Reader, g Geometry, c Config) error {
+	m, err := MaskFromFeature(mask, field, value)
+	if err != nil {
+		return err
+	}
+	c.ClipMask = append(c.ClipMask, m...)
+	return renderloop(dest, detail, g, c, nil, nil)
+}
+
+// snapkey is a point rounded to a grid, so that a vertex computed twice,
+// once on each side of a shared mask edge, compares equal
+type snapkey [2]int64
+
+// snapper returns the grid rounding for a set of pieces, scaled to their extent
+func snapper(pieces [][]shp.Point) func(shp.Point) snapkey {
+	m := 1.0
+	for _, piece := range pieces {
+		for _, p := range piece {
+			m = max(m, math.Abs(p.X), math.Abs(p.Y))
+		}
+	}
+	eps := m * 1e-9
+	return func(p shp.Point) snapkey {
+		return snapkey{int64(math.Round(p.X / eps)), int64(math.Round(p.Y / eps))}
+	}
+}
+
+// mergefragments joins the pieces of a ring clipped to the triangles of a
+// mask back into whole rings: edges shared by two fragments, the mask
+// diagonals, cancel, and the edges that remain are chained into rings.
+// Outer rings come back clockwise and holes counter-clockwise.
+func mergefragments(frags [][]shp.Point) [][]shp.Point {
+	if len(frags) < 2 {
+		return frags
+	}
+	type edge struct {
+		a, b   shp.Point
+		ka, kb snapkey
+		dead   bool
+	}
+	key := snapper(frags)
+	var edges []edge
+	for _, frag := range frags {
+		if n := len(frag); n > 1 && frag[0] == frag[n-1] {
+			frag = frag[:n-1]
+		}
+		if signedArea(frag) > 0 {
+			frag = reversed(frag)
+		}
+		for i, p := range frag {
+			q := frag[(i+1)%len(frag)]
+			if kp, kq := key(p), key(q); kp != kq {
+				edges = append(edges, edge{a: p, b: q, ka: kp, kb: kq})
+			}
+		}
+	}
+	pending := map[[2]snapkey][]int{}
+	for i, e := range edges {
+		rev := [2]snapkey{e.kb, e.ka}
+		if l := pending[rev]; len(l) > 0 {
+			edges[i].dead, edges[l[len(l)-1]].dead = true, true
+			pending[rev] = l[:len(l)-1]
+			continue
+		}
+		pending[[2]snapkey{e.ka, e.kb}] = append(pending[[2]snapkey{e.ka, e.kb}], i)
+	}
+	from := map[snapkey][]int{}
+	for i, e := range edges {
+		if !e.dead {
+			from[e.ka] = append(from[e.ka], i)
+		}
+	}
+	var rings [][]shp.Point
+	for i := range edges {
+		if edges[i].dead {
+			continue
+		}
+		start := edges[i].ka
+		ring := []shp.Point{edges[i].a}
+		for cur := i; ; {
+			edges[cur].dead = true
+			e := edges[cur]
+			if e.kb == start {
+				break
+			}
+			ring = append(ring, e.b)
+			next := -1
+			for _, j := range from[e.kb] {
+				if !edges[j].dead {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			cur = next
+		}
+		if len(ring) > 2 {
+			rings = append(rings, append(ring, ring[0]))
+		}
+	}
+	return rings
+}
+
+// joinlines chains lines clipped to the triangles of a mask back together
+// wherever one ends where another starts. Lines that only touch a triangle
+// at a point are dropped.
+func joinlines(lines [][]shp.Point) [][]shp.Point {
+	key := snapper(lines)
+	var kept [][]shp.Point
+	for _, line := range lines {
+		line = slices.CompactFunc(slices.Clone(line), func(p, q shp.Point) bool { return key(p) == key(q) })
+		if len(line) > 1 {
+			kept = append(kept, line)
+		}
+	}
+	lines = kept
+	for joined := true; joined; {
+		joined = false
+		for i := 0; i < len(lines) && !joined; i++ {
+			end := key(lines[i][len(lines[i])-1])
+			for j := range lines {
+				if j != i && key(lines[j][0]) == end {
+					lines[i] = append(slices.Clip(lines[i]), lines[j][1:]...)
+					lines = slices.Delete(lines, j, j+1)
+					joined = true
+					break
+				}
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,84 @@
+package shpdeck
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// ell is an L-shaped ring: the unit square with its upper right quarter removed
+var ell = []shp.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 5}, {X: 5, Y: 5}, {X: 5, Y: 10}, {X: 0, Y: 10}}
+
+func TestTriangulate(t *testing.T) {
+	donut := shp.Polygon(*shp.NewPolyLine([][]shp.Point{
+		{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 0}, {X: 0, Y: 0}},
+		{{X: 3, Y: 3}, {X: 7, Y: 3}, {X: 7, Y: 7}, {X: 3, Y: 7}, {X: 3, Y: 3}},
+	}))
+	tests := []struct {
+		name string
+		tris [][]shp.Point
+		area float64
+	}{
+		{"square", Triangulate(square(0, 0, 10).Points), 100},
+		{"ell", Triangulate(ell), 75},
+		{"donut", polygonmask(&donut), 84},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := 0.0
+			for _, tri := range tt.tris {
+				a += math.Abs(signedArea(tri))
+			}
+			if math.Abs(a-tt.area) > 1e-9 {
+				t.Errorf("triangles cover %v, want %v", a, tt.area)
+			}
+		})
+	}
+}
+
+// TestClipMaskSeams checks that clipping to a triangulated mask draws
+// neither the triangle edges in outlines nor seams between filled fragments
+func TestClipMaskSeams(t *testing.T) {
+	tests := []struct {
+		name  string
+		shape *shp.Polygon
+		edge  float64 // where the outline of shape is on screen, low and high
+	}{
+		{"covering", square(0, 0, 10), 0},
+		{"inside", square(2, 2, 6), 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.ClipMask = Triangulate(ell)
+			var b strings.Builder
+			PolygonCoords(&b, tt.shape, unit, c)
+			if n := strings.Count(b.String(), "<polygon "); n != 1 {
+				t.Errorf("fill is drawn as %d polygons, want 1:\n%s", n, b.String())
+			}
+
+			c = NewConfig("line", "red", 0.2)
+			c.ClipMask = Triangulate(ell)
+			b.Reset()
+			PolygonCoords(&b, tt.shape, unit, c)
+			segs := lineelements(t, b.String())
+			if len(segs) == 0 {
+				t.Fatal("no outline drawn")
+			}
+			lo, hi := tt.edge, 100-tt.edge
+			for _, s := range segs {
+				onedge := func(v float64) bool { return v == lo || v == hi }
+				vertical := s[0] == s[2] && onedge(s[0])
+				horizontal := s[1] == s[3] && onedge(s[1])
+				if !vertical && !horizontal {
+					t.Errorf("line %v is not on the outline", s)
+				}
+				if s[0] == s[2] && s[1] == s[3] {
+					t.Errorf("line %v has no length", s)
+				}
+			}
+		})
+	}
+}
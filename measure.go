@@ -0,0 +1,73 @@
+package shpdeck
+
+import (
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// partpoints splits the points of a multi-part shape into its parts
+func partpoints(points []shp.Point, parts []int32, numpoints int32) [][]shp.Point {
+	numpoints = min(numpoints, int32(len(points)))
+	parts = normalizeparts(parts, numpoints, Config{})
+	out := make([][]shp.Point, 0, len(parts))
+	for i := range parts {
+		start, end := partrange(parts, numpoints, i)
+		out = append(out, points[start:end])
+	}
+	return out
+}
+
+// ringsarea is the area enclosed by rings, with holes wound opposite to
+// their outer rings subtracted
+func ringsarea(rings [][]shp.Point) float64 {
+	a := 0.0
+	for _, r := range rings {
+		a += signedArea(r)
+	}
+	return math.Abs(a)
+}
+
+// pathlength is the total length of the segments of each path
+func pathlength(paths [][]shp.Point) float64 {
+	d := 0.0
+	for _, p := range paths {
+		for i := 1; i < len(p); i++ {
+			d += math.Hypot(p[i].X-p[i-1].X, p[i].Y-p[i-1].Y)
+		}
+	}
+	return d
+}
+
+// screenparts maps the parts to the screen
+func screenparts(parts [][]shp.Point, g Geometry, c Config) [][]shp.Point {
+	out := make([][]shp.Point, len(parts))
+	for i, p := range parts {
+		out[i] = make([]shp.Point, len(p))
+		for j, pt := range p {
+			out[i][j].X, out[i][j].Y = c.mappoint(pt, g)
+		}
+	}
+	return out
+}
+
+// PolygonArea is the area of a polygon in source units squared (square degrees
+// for geographic data), with holes subtracted
+func PolygonArea(poly *shp.Polygon) float64 {
+	return ringsarea(partpoints(poly.Points, poly.Parts, poly.NumPoints))
+}
+
+// PolylineLength is the length of all parts of a polyline in source units
+func PolylineLength(poly *shp.PolyLine) float64 {
+	return pathlength(partpoints(poly.Points, poly.Parts, poly.NumPoints))
+}
+
+// ScreenArea is the area of a polygon once mapped from g to the Config screen box
+func ScreenArea(poly *shp.Polygon, g Geometry, c Config) float64 {
+	return ringsarea(screenparts(partpoints(poly.Points, poly.Parts, poly.NumPoints), g, c))
+}
+
+// ScreenLength is the length of a polyline once mapped from g to the Config screen box
+func ScreenLength(poly *shp.PolyLine, g Geometry, c Config) float64 {
+	return pathlength(screenparts(partpoints(poly.Points, poly.Parts, poly.NumPoints), g, c))
+}
@@ -0,0 +1,58 @@
+package shpdeck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestPolygonArea(t *testing.T) {
+	donut := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, holeA}))
+	two := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, outerB}))
+	triangle := shp.Polygon(*shp.NewPolyLine([][]shp.Point{{{X: 0, Y: 0}, {X: 0, Y: 3}, {X: 4, Y: 0}, {X: 0, Y: 0}}}))
+	tests := []struct {
+		name   string
+		poly   *shp.Polygon
+		area   float64
+		screen float64 // on the unit geometry, ten times the scale
+	}{
+		{"square", square(0, 0, 2), 4, 400},
+		{"triangle", &triangle, 6, 600},
+		{"square with a hole", &donut, 12, 1200},
+		{"two squares", &two, 32, 3200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a := PolygonArea(tt.poly); math.Abs(a-tt.area) > 1e-9 {
+				t.Errorf("PolygonArea = %v, want %v", a, tt.area)
+			}
+			if a := ScreenArea(tt.poly, unit, NewConfig("polygon", "red", 0)); math.Abs(a-tt.screen) > 1e-6 {
+				t.Errorf("ScreenArea = %v, want %v", a, tt.screen)
+			}
+		})
+	}
+}
+
+func TestPolylineLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		parts  [][]shp.Point
+		length float64
+	}{
+		{"3-4-5", [][]shp.Point{{{X: 0, Y: 0}, {X: 3, Y: 4}}}, 5},
+		{"bent", [][]shp.Point{{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}}}, 3},
+		{"two parts", [][]shp.Point{{{X: 0, Y: 0}, {X: 1, Y: 0}}, {{X: 5, Y: 5}, {X: 5, Y: 7}}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := shp.NewPolyLine(tt.parts)
+			if d := PolylineLength(line); math.Abs(d-tt.length) > 1e-9 {
+				t.Errorf("PolylineLength = %v, want %v", d, tt.length)
+			}
+			if d := ScreenLength(line, unit, NewConfig("line", "red", 0)); math.Abs(d-10*tt.length) > 1e-6 {
+				t.Errorf("ScreenLength = %v, want %v", d, 10*tt.length)
+			}
+		})
+	}
+}
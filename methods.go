@@ -0,0 +1,46 @@
+package shpdeck
+
+import (
+	"io"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Polygon is PolygonCoords with this Config, for fluent use with a Config
+// from NewConfig:
+//
+//	c := shpdeck.NewConfig("polygon", "tan", 0)
+//	c.Polygon(w, poly, g)
+func (c Config) Polygon(dest io.Writer, poly *shp.Polygon, g Geometry) {
+	PolygonCoords(dest, poly, g, c)
+}
+
+// Polyline is PolylineCoords with this Config
+func (c Config) Polyline(dest io.Writer, poly *shp.PolyLine, g Geometry) {
+	PolylineCoords(dest, poly, g, c)
+}
+
+// MultiPoint is MultipointCoords with this Config
+func (c Config) MultiPoint(dest io.Writer, mp *shp.MultiPoint, g Geometry) {
+	MultipointCoords(dest, mp, g, c)
+}
+
+// Point is PointCoords with this Config
+func (c Config) Point(dest io.Writer, p *shp.Point, g Geometry) {
+	PointCoords(dest, p, g, c)
+}
+
+// Shape is RenderShape with this Config
+func (c Config) Shape(dest io.Writer, s shp.Shape, g Geometry) {
+	RenderShape(dest, s, g, c)
+}
+
+// Shapes is RenderShapes with this Config
+func (c Config) Shapes(dest io.Writer, shapes []shp.Shape, g Geometry) error {
+	return RenderShapes(dest, shapes, g, c)
+}
+
+// File is RenderFile with this Config
+func (c Config) File(dest io.Writer, filename string, g Geometry) (Stats, error) {
+	return RenderFile(dest, filename, g, c)
+}
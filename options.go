@@ -0,0 +1,59 @@
+package shpdeck
+
+import (
+	"io"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderOptions gathers everything a render needs in one place.
+// The embedded Config carries the optional features (clipping, patterns,
+// logging, output format, ...); new options are added there or here
+// rather than as new function arguments.
+type RenderOptions struct {
+	Config
+	Geometry Geometry // screen box and geographic bounds
+	MapType  string   // "polygon", "line" or "dot" (and their short forms)
+	Color    string   // color, with optional opacity as "name:op"
+	Size     float64  // line width or dot size
+}
+
+// NewConfig returns a Config that draws features as mapType ("polygon",
+// "line" or "dot", and their short forms) in color, with optional opacity
+// as "name:op", at size (line width or dot size). The style can only be set
+// this way (or by RenderOptions); set the other options on the result:
+//
+//	c := shpdeck.NewConfig("polygon", "steelblue", 0)
+//	c.ClipToScreen = true
+//	shpdeck.RenderFile(w, "countries.shp", g, c)
+func NewConfig(mapType, color string, size float64) Config {
+	return Config{maptype: mapType, color: color, shapesize: size}
+}
+
+// config returns the Config described by the options
+func (o RenderOptions) config() Config {
+	c := o.Config
+	c.maptype = o.MapType
+	c.color = o.Color
+	c.shapesize = o.Size
+	return c
+}
+
+// Render renders every record of source according to opts.
+//
+// It replaces calling the per-shape functions in a read loop:
+//
+//	for r.Next() {
+//		_, s := r.Shape()
+//		shpdeck.PolygonCoords(w, s.(*shp.Polygon), g, c)
+//	}
+//
+// becomes
+//
+//	shpdeck.Render(w, r, shpdeck.RenderOptions{Geometry: g, MapType: "polygon", Color: "red"})
+//
+// PolygonCoords, PolylineCoords, MultipointCoords and PointCoords remain
+// for rendering single shapes.
+func Render(dest io.Writer, source *shp.Reader, opts RenderOptions) (Stats, error) {
+	return RenderReaders(dest, []*shp.Reader{source}, opts.Geometry, opts.config())
+}
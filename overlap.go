@@ -0,0 +1,70 @@
+package shpdeck
+
+import (
+	"fmt"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// overlapfeature is a polygon already drawn, for overlap checks
+type overlapfeature struct {
+	row  int
+	box  shp.Box
+	area float64
+	tris [][]shp.Point // triangles of the outer rings
+}
+
+// overlapcheck finds polygons that overlap ones drawn before them
+type overlapcheck struct {
+	seen []overlapfeature
+}
+
+// outerrings returns the outer rings of a polygon; holes are ignored
+func outerrings(p *shp.Polygon, w Winding) [][]shp.Point {
+	var outer [][]shp.Point
+	for _, group := range ringgroups(normalizewinding(partpoints(p.Points, p.Parts, p.NumPoints), w)) {
+		outer = append(outer, group[0])
+	}
+	return outer
+}
+
+func boxesoverlap(a, b shp.Box) bool {
+	return a.MinX < b.MaxX && b.MinX < a.MaxX && a.MinY < b.MaxY && b.MinY < a.MaxY
+}
+
+// check compares a polygon with those seen before and reports each overlap
+// covering more than a millionth of the smaller area, so that shared borders
+// do not count
+func (o *overlapcheck) check(row int, s shp.Shape, c Config) {
+	p, ok := s.(*shp.Polygon)
+	if !ok {
+		return
+	}
+	rings := outerrings(p, c.AssumeWinding)
+	f := overlapfeature{row: row, box: p.BBox(), area: ringsarea(rings)}
+	for _, r := range rings {
+		f.tris = append(f.tris, Triangulate(r)...)
+	}
+	for _, prev := range o.seen {
+		if !boxesoverlap(f.box, prev.box) {
+			continue
+		}
+		shared := 0.0
+		for _, r := range rings {
+			for _, t := range prev.tris {
+				if clipped := ClipPolygon(r, t); len(clipped) > 2 {
+					shared += ringsarea([][]shp.Point{clipped})
+				}
+			}
+		}
+		if shared > 1e-6*min(f.area, prev.area) {
+			if c.stats != nil {
+				c.stats.Overlaps++
+			}
+			if c.Logger != nil {
+				c.Logger(fmt.Sprintf("record %d: overlaps record %d, where opacities stack", row, prev.row))
+			}
+		}
+	}
+	o.seen = append(o.seen, f)
+}
@@ -0,0 +1,22 @@
+package shpdeck
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderOverlay draws layer a, then layer b on top of it, for before/after
+// comparisons. This is a visual overlay, not a geometric difference: where
+// the layers agree b covers a, and where they differ both show. If styleB's
+// color has no opacity it is drawn at 50 so a shows through.
+func RenderOverlay(dest io.Writer, a, b *shp.Reader, g Geometry, styleA, styleB Config) error {
+	if !strings.Contains(styleB.color, ":") {
+		styleB.color += ":50"
+	}
+	if err := renderloop(dest, a, g, styleA, nil, nil); err != nil {
+		return err
+	}
+	return renderloop(dest, b, g, styleB, nil, nil)
+}
@@ -0,0 +1,48 @@
+package shpdeck
+
+import (
+	"sort"
+	"sync"
+)
+
+// the palette registry is safe for concurrent use
+var (
+	palettemu sync.RWMutex
+	palettes  = map[string]Palette{
+		"blues":   {"#f7fbff", "#c6dbef", "#6baed6", "#2171b5", "#08306b"},
+		"greens":  {"#f7fcf5", "#c7e9c0", "#74c476", "#238b45", "#00441b"},
+		"reds":    {"#fff5f0", "#fcbba1", "#fb6a4a", "#cb181d", "#67000d"},
+		"greys":   {"#ffffff", "#d9d9d9", "#969696", "#525252", "#000000"},
+		"viridis": {"#440154", "#3b528b", "#21918c", "#5ec962", "#fde725"},
+		"rdbu":    {"#b2182b", "#ef8a62", "#f7f7f7", "#67a9cf", "#2166ac"},
+		"set1":    {"#e41a1c", "#377eb8", "#4daf4a", "#984ea3", "#ff7f00", "#ffff33", "#a65628", "#f781bf"},
+	}
+)
+
+// RegisterPalette adds or replaces a named palette.
+// It is safe to call concurrently with rendering.
+func RegisterPalette(name string, p Palette) {
+	palettemu.Lock()
+	defer palettemu.Unlock()
+	palettes[name] = append(Palette(nil), p...)
+}
+
+// PaletteNamed returns a copy of a registered palette
+func PaletteNamed(name string) (Palette, bool) {
+	palettemu.RLock()
+	defer palettemu.RUnlock()
+	p, ok := palettes[name]
+	return append(Palette(nil), p...), ok
+}
+
+// PaletteNames lists the registered palettes in order
+func PaletteNames() []string {
+	palettemu.RLock()
+	defer palettemu.RUnlock()
+	names := make([]string, 0, len(palettes))
+	for n := range palettes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
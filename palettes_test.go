@@ -0,0 +1,67 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestPaletteNamed(t *testing.T) {
+	RegisterPalette("test-pair", Palette{"red", "blue"})
+	tests := []struct {
+		name string
+		want Palette
+		ok   bool
+	}{
+		{"set1", Palette{"#e41a1c", "#377eb8", "#4daf4a", "#984ea3", "#ff7f00", "#ffff33", "#a65628", "#f781bf"}, true},
+		{"test-pair", Palette{"red", "blue"}, true},
+		{"no such palette", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := PaletteNamed(tt.name)
+			if ok != tt.ok || !slices.Equal(p, tt.want) {
+				t.Errorf("PaletteNamed = %v, %v, want %v, %v", p, ok, tt.want, tt.ok)
+			}
+			if len(p) > 0 {
+				p[0] = "changed" // a copy, which leaves the registry alone
+				if again, _ := PaletteNamed(tt.name); again[0] == "changed" {
+					t.Error("PaletteNamed returns the registered slice")
+				}
+			}
+		})
+	}
+}
+
+// TestRegistriesConcurrent registers palettes and renderers while other
+// goroutines render with them; run it with -race
+func TestRegistriesConcurrent(t *testing.T) {
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, holeA, outerB}))
+	c := NewConfig("polygon", "red", 0)
+	c.DebugParts = true // reads the "set1" palette
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				RegisterPalette(fmt.Sprintf("test-%d-%d", i, j), Palette{"red"})
+				RegisterRenderer(shp.POLYLINEM, func(w io.Writer, s shp.Shape, g Geometry, c Config) {})
+				PaletteNames()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				RenderShape(io.Discard, &poly, unit, c)
+				RenderShape(io.Discard, &shp.PolyLineM{}, unit, c)
+				PaletteNamed("test-0-0")
+			}
+		}()
+	}
+	wg.Wait()
+}
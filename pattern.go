@@ -0,0 +1,119 @@
+package shpdeck
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// fill pattern names for Config.FillPattern
+const (
+	PatternHatch      = "hatch"      // diagonal lines, lower left to upper right
+	PatternBackHatch  = "backhatch"  // diagonal lines, upper left to lower right
+	PatternCrossHatch = "crosshatch" // both diagonals
+	PatternHorizontal = "horizontal"
+	PatternVertical   = "vertical"
+	PatternDots       = "dots"
+)
+
+// patternAngles are the line angles, in degrees, that make up each line pattern
+var patternAngles = map[string][]float64{
+	PatternHatch:      {45},
+	PatternBackHatch:  {-45},
+	PatternCrossHatch: {45, -45},
+	PatternHorizontal: {0},
+	PatternVertical:   {90},
+}
+
+// deckpattern approximates a fill pattern inside the polygon (x, y)
+// with lines or dots clipped to the polygon
+func deckpattern(w io.Writer, x, y []float64, c Config) {
+	if len(x) < 3 || len(x) != len(y) {
+		return
+	}
+	spacing := c.PatternSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+	color := c.PatternColor
+	if color == "" {
+		color = "black"
+	}
+	size := c.shapesize
+	if size <= 0 {
+		size = 0.1
+	}
+	fill, op := colorattr(color)
+	if c.FillPattern == PatternDots {
+		xmin, xmax, ymin, ymax := bounds(x, y)
+		for py := ymin + spacing/2; py < ymax; py += spacing {
+			for px := xmin + spacing/2; px < xmax; px += spacing {
+				if pointinpoly(px, py, x, y) {
+					c.dot(w, px, py, fill, op, size)
+				}
+			}
+		}
+		return
+	}
+	for _, a := range patternAngles[c.FillPattern] {
+		for _, s := range hatchlines(x, y, a*math.Pi/180, spacing) {
+			c.line(w, s[0], s[1], s[2], s[3], fill, op, size)
+		}
+	}
+}
+
+// bounds returns the extent of a set of coordinates
+func bounds(x, y []float64) (xmin, xmax, ymin, ymax float64) {
+	xmin, xmax, ymin, ymax = x[0], x[0], y[0], y[0]
+	for i := range x {
+		xmin, xmax = min(xmin, x[i]), max(xmax, x[i])
+		ymin, ymax = min(ymin, y[i]), max(ymax, y[i])
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+// pointinpoly is the even-odd point in polygon test
+func pointinpoly(px, py float64, x, y []float64) bool {
+	in := false
+	n := len(x)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		if (y[i] > py) != (y[j] > py) && px < (x[j]-x[i])*(py-y[i])/(y[j]-y[i])+x[i] {
+			in = !in
+		}
+	}
+	return in
+}
+
+// hatchlines returns the segments (x1, y1, x2, y2) of parallel lines at angle
+// theta, spaced apart by spacing, that fall inside the polygon (x, y).
+// The polygon is rotated so the lines are horizontal, then scanned even-odd.
+func hatchlines(x, y []float64, theta, spacing float64) [][4]float64 {
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	n := len(x)
+	u := make([]float64, n)
+	v := make([]float64, n)
+	for i := range n {
+		u[i] = x[i]*cos + y[i]*sin
+		v[i] = -x[i]*sin + y[i]*cos
+	}
+	_, _, vmin, vmax := bounds(u, v)
+	var segs [][4]float64
+	var cross []float64
+	for sv := vmin + spacing/2; sv < vmax; sv += spacing {
+		cross = cross[:0]
+		for i, j := 0, n-1; i < n; j, i = i, i+1 {
+			if (v[i] > sv) != (v[j] > sv) {
+				cross = append(cross, u[i]+(u[j]-u[i])*(sv-v[i])/(v[j]-v[i]))
+			}
+		}
+		sort.Float64s(cross)
+		for k := 0; k+1 < len(cross); k += 2 {
+			u1, u2 := cross[k], cross[k+1]
+			segs = append(segs, [4]float64{
+				u1*cos - sv*sin, u1*sin + sv*cos,
+				u2*cos - sv*sin, u2*sin + sv*cos,
+			})
+		}
+	}
+	return segs
+}
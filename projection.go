@@ -0,0 +1,95 @@
+package shpdeck
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Projection converts longitude and latitude to planar coordinates before
+// they are mapped to the screen; the Geometry geographic bounds are then in
+// projected units
+type Projection interface {
+	Project(lon, lat float64) (x, y float64)
+}
+
+// A projection that implements fmt.Stringer is named by its String method in
+// the Config.BoundsComment, and otherwise by its Go type.
+
+// Graticuler is implemented by projections whose graticule is not the
+// rectangular grid over the Geometry bounds, returning the lines of the
+// graticule every step degrees as longitude and latitude points
+type Graticuler interface {
+	Graticule(step float64) [][]shp.Point
+}
+
+const radians = math.Pi / 180
+
+// AzimuthalEquidistant centers the map on (Lon0, Lat0), keeping distances
+// and directions from there true. Projected units are degrees of arc from
+// the center; Lat0 of 90 or -90 gives the polar case.
+type AzimuthalEquidistant struct {
+	Lon0, Lat0 float64
+	Extent     float64 // degrees from the center covered by the graticule; zero means 90
+}
+
+// arc is the angular distance in radians of a point from the center
+func (a AzimuthalEquidistant) arc(lon, lat float64) float64 {
+	phi0, phi, dl := a.Lat0*radians, lat*radians, (lon-a.Lon0)*radians
+	return math.Acos(clamp(math.Sin(phi0)*math.Sin(phi)+math.Cos(phi0)*math.Cos(phi)*math.Cos(dl), -1, 1))
+}
+
+// String names the projection and its center, for Config.BoundsComment
+func (a AzimuthalEquidistant) String() string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return "azimuthal equidistant lon0=" + f(a.Lon0) + " lat0=" + f(a.Lat0)
+}
+
+// Project implements Projection
+func (a AzimuthalEquidistant) Project(lon, lat float64) (float64, float64) {
+	phi0, phi, dl := a.Lat0*radians, lat*radians, (lon-a.Lon0)*radians
+	c := a.arc(lon, lat)
+	k := 1.0
+	if s := math.Sin(c); s > 1e-12 {
+		k = c / s
+	}
+	x := k * math.Cos(phi) * math.Sin(dl)
+	y := k * (math.Cos(phi0)*math.Sin(phi) - math.Sin(phi0)*math.Cos(phi)*math.Cos(dl))
+	return x / radians, y / radians
+}
+
+// Graticule implements Graticuler: meridians and parallels over the whole
+// globe, cut to within Extent of the center. In the polar case these are
+// radial meridians and concentric circles of latitude.
+func (a AzimuthalEquidistant) Graticule(step float64) [][]shp.Point {
+	extent := a.Extent
+	if extent <= 0 {
+		extent = 90
+	}
+	world := Geometry{Longmin: -180, Longmax: 180, Latmin: -90, Latmax: 90}
+	var lines [][]shp.Point
+	for _, l := range graticulelines(world, step, 180) {
+		if l[0].X == 180 && l[len(l)-1].X == 180 {
+			continue // the meridian at 180, which is the one at -180
+		}
+		if math.Abs(l[0].Y) == 90 && l[0].Y == l[len(l)-1].Y {
+			continue // the parallel at a pole is a point
+		}
+		var run []shp.Point
+		for _, p := range l {
+			if a.arc(p.X, p.Y) <= extent*radians+1e-9 {
+				run = append(run, p)
+				continue
+			}
+			if len(run) > 1 {
+				lines = append(lines, run)
+			}
+			run = nil
+		}
+		if len(run) > 1 {
+			lines = append(lines, run)
+		}
+	}
+	return lines
+}
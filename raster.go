@@ -0,0 +1,164 @@
+package shpdeck
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// rastercolor converts a "name:op" color, drawing unknown names in gray
+func rastercolor(s string) color.NRGBA {
+	name, op := ColorOp(s)
+	c, ok := NamedColor(name)
+	if !ok {
+		c = cssColors["gray"]
+	}
+	o, _ := strconv.ParseFloat(op, 64)
+	return color.NRGBA{c.R, c.G, c.B, uint8(math.Round(o / 100 * 255))}
+}
+
+// raster draws mapped shapes into an image
+type raster struct {
+	img    *image.RGBA
+	g      Geometry
+	x0, y0 float64 // the lower left of the screen box
+	sx, sy float64 // pixels per screen unit
+}
+
+// pixel converts a screen coordinate to the image, y down
+func (r *raster) pixel(x, y float64) (float64, float64) {
+	return (x - r.x0) * r.sx, float64(r.img.Rect.Dy()) - (y-r.y0)*r.sy
+}
+
+// blend paints one pixel with c over what is there
+func (r *raster) blend(x, y int, c color.NRGBA) {
+	if !(image.Point{x, y}.In(r.img.Rect)) {
+		return
+	}
+	d := r.img.RGBAAt(x, y)
+	a := uint32(c.A)
+	mix := func(s, d uint8) uint8 { return uint8((uint32(s)*a + uint32(d)*(255-a)) / 255) }
+	r.img.SetRGBA(x, y, color.RGBA{mix(c.R, d.R), mix(c.G, d.G), mix(c.B, d.B), 255})
+}
+
+// fill scanline fills rings given in pixels, even-odd, sampling pixel centers
+func (r *raster) fill(rings [][]shp.Point, c color.NRGBA) {
+	ymin, ymax := math.Inf(1), math.Inf(-1)
+	for _, ring := range rings {
+		for _, p := range ring {
+			ymin, ymax = min(ymin, p.Y), max(ymax, p.Y)
+		}
+	}
+	for py := int(math.Max(math.Floor(ymin), 0)); py <= int(math.Min(ymax, float64(r.img.Rect.Dy()-1))); py++ {
+		y := float64(py) + 0.5
+		var xs []float64
+		for _, ring := range rings {
+			for i := range ring {
+				a, b := ring[i], ring[(i+1)%len(ring)]
+				if (a.Y > y) != (b.Y > y) {
+					xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+				}
+			}
+		}
+		slices.Sort(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			for px := int(math.Ceil(xs[i] - 0.5)); float64(px)+0.5 < xs[i+1]; px++ {
+				r.blend(px, py, c)
+			}
+		}
+	}
+}
+
+// line draws a one pixel line, stepping along its longer axis
+func (r *raster) line(a, b shp.Point, c color.NRGBA) {
+	n := int(math.Ceil(math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y))))
+	for i := 0; i <= n; i++ {
+		t := 0.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		r.blend(int(a.X+t*(b.X-a.X)), int(a.Y+t*(b.Y-a.Y)), c)
+	}
+}
+
+// dot draws a filled circle of the given diameter in screen units
+func (r *raster) dot(p shp.Point, size float64, c color.NRGBA) {
+	rad := math.Max(size/2*r.sx, 0.5)
+	for y := int(p.Y - rad); y <= int(p.Y+rad); y++ {
+		for x := int(p.X - rad); x <= int(p.X+rad); x++ {
+			if math.Hypot(float64(x)+0.5-p.X, float64(y)+0.5-p.Y) <= rad {
+				r.blend(x, y, c)
+			}
+		}
+	}
+}
+
+// pixels maps geographic points to the image
+func (r *raster) pixels(pts []shp.Point, c Config) []shp.Point {
+	out := make([]shp.Point, len(pts))
+	for i, p := range pts {
+		x, y := c.mappoint(p, r.g)
+		out[i].X, out[i].Y = r.pixel(x, y)
+	}
+	return out
+}
+
+// RenderPNG draws a raster preview of shapes, the screen box (0..100 with
+// Relative) filling a width by height image on white: polygons filled (or
+// outlined, unless the Config shape is a polygon), polylines as lines and
+// points as dots, in the Config color, which may be a hex color or a CSS
+// color name (see NamedColor).
+func RenderPNG(w io.Writer, shapes []shp.Shape, g Geometry, c Config, width, height int) error {
+	box := c.screenbox(g)
+	r := &raster{
+		img: image.NewRGBA(image.Rect(0, 0, width, height)),
+		g:   g,
+		x0:  box[0].X,
+		y0:  box[0].Y,
+		sx:  float64(width) / (box[2].X - box[0].X),
+		sy:  float64(height) / (box[2].Y - box[0].Y),
+	}
+	for i := range r.img.Pix {
+		r.img.Pix[i] = 0xff
+	}
+	col := rastercolor(c.color)
+	for _, s := range shapes {
+		switch v := s.(type) {
+		case *shp.Polygon:
+			parts := partpoints(v.Points, v.Parts, v.NumPoints)
+			rings := make([][]shp.Point, len(parts))
+			for i, p := range parts {
+				rings[i] = r.pixels(p, c)
+			}
+			if ispolygon(c.maptype) {
+				r.fill(rings, col)
+				continue
+			}
+			for _, ring := range rings {
+				for i := range ring {
+					r.line(ring[i], ring[(i+1)%len(ring)], col)
+				}
+			}
+		case *shp.PolyLine:
+			for _, p := range partpoints(v.Points, v.Parts, v.NumPoints) {
+				pts := r.pixels(p, c)
+				for i := 1; i < len(pts); i++ {
+					r.line(pts[i-1], pts[i], col)
+				}
+			}
+		case *shp.MultiPoint:
+			for _, p := range r.pixels(v.Points, c) {
+				r.dot(p, c.shapesize, col)
+			}
+		case *shp.Point:
+			r.dot(r.pixels([]shp.Point{*v}, c)[0], c.shapesize, col)
+		}
+	}
+	return png.Encode(w, r.img)
+}
@@ -0,0 +1,34 @@
+package shpdeck
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestRenderPNGRelative fills the image with the screen box that shapes are
+// mapped to, which is 0..100 with Relative whatever the Geometry
+func TestRenderPNGRelative(t *testing.T) {
+	g := Geometry{Xmin: 200, Xmax: 600, Ymin: 200, Ymax: 600, Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+	for _, relative := range []bool{false, true} {
+		c := NewConfig("polygon", "black", 0)
+		c.Relative = relative
+		var b bytes.Buffer
+		if err := RenderPNG(&b, []shp.Shape{square(5, 5, 2)}, g, c, 10, 10); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the square covers the middle of the image, y down, and not the corner
+		if r, _, _, _ := img.At(5, 4).RGBA(); r != 0 {
+			t.Errorf("relative %v: the middle pixel is not filled", relative)
+		}
+		if r, _, _, _ := img.At(0, 9).RGBA(); r == 0 {
+			t.Errorf("relative %v: the corner pixel is filled", relative)
+		}
+	}
+}
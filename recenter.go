@@ -0,0 +1,128 @@
+package shpdeck
+
+import (
+	"math"
+	"slices"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// wraplon wraps a longitude into [-180, 180)
+func wraplon(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// recenterpoint shifts a point's longitude relative to Config.CentralMeridian
+func (c Config) recenterpoint(p shp.Point) shp.Point {
+	if c.CentralMeridian != 0 {
+		p.X = wraplon(p.X - c.CentralMeridian)
+	}
+	return p
+}
+
+// recenter shifts the longitudes of a part relative to Config.CentralMeridian,
+// and splits it where it crosses the seam 180 degrees away. Each edge is taken
+// the short way around, so the part is first unwrapped into continuous
+// longitudes, then clipped against the [-180, 180] window and its copies
+// shifted a turn either way. With seam set, the part is split at the
+// antimeridian even with no CentralMeridian, as for great circle routes.
+func (c Config) recenter(part []shp.Point, closed, seam bool) [][]shp.Point {
+	if (c.CentralMeridian == 0 && !seam) || len(part) == 0 {
+		return [][]shp.Point{part}
+	}
+	out := make([]shp.Point, len(part))
+	out[0] = c.recenterpoint(part[0])
+	lo, hi := out[0].X, out[0].X
+	ylo, yhi := out[0].Y, out[0].Y
+	for i := 1; i < len(part); i++ {
+		out[i] = part[i]
+		out[i].X = out[i-1].X + wraplon(part[i].X-part[i-1].X)
+		lo, hi = min(lo, out[i].X), max(hi, out[i].X)
+		ylo, yhi = min(ylo, out[i].Y), max(yhi, out[i].Y)
+	}
+	if lo >= -180 && hi <= 180 {
+		return [][]shp.Point{out}
+	}
+	ylo, yhi = ylo-1, yhi+1
+	window := []shp.Point{{X: -180, Y: ylo}, {X: 180, Y: ylo}, {X: 180, Y: yhi}, {X: -180, Y: yhi}}
+	var pieces [][]shp.Point
+	for _, turn := range []float64{-360, 0, 360} {
+		if hi+turn < -180 || lo+turn > 180 {
+			continue
+		}
+		shifted := make([]shp.Point, len(out))
+		for i, p := range out {
+			shifted[i] = shp.Point{X: p.X + turn, Y: p.Y}
+		}
+		if closed {
+			if clipped := ClipPolygon(shifted, window); len(clipped) > 0 {
+				pieces = append(pieces, clipped)
+			}
+		} else {
+			pieces = append(pieces, ClipPolyline(shifted, window)...)
+		}
+	}
+	return pieces
+}
+
+// LongitudeRange returns the shortest arc of longitude holding every one of
+// lons, from lo eastward to hi. Data that crosses the antimeridian, such as
+// Fiji or the Aleutians, has the gap in its coverage elsewhere, and its range
+// is given in a frame shifted past it: lo in [-180, 180), and hi above 180.
+// Unless the shift saves more than a degree, which nearly global data never
+// does, the range is the plain minimum and maximum.
+func LongitudeRange(lons []float64) (lo, hi float64) {
+	if len(lons) == 0 {
+		return 0, 0
+	}
+	lo, hi = slices.Min(lons), slices.Max(lons)
+	w := make([]float64, len(lons))
+	for i, lon := range lons {
+		w[i] = wraplon(lon)
+	}
+	slices.Sort(w)
+	gap, k := 0.0, -1
+	for i := 1; i < len(w); i++ {
+		if d := w[i] - w[i-1]; d > gap {
+			gap, k = d, i
+		}
+	}
+	if k < 0 || 360-gap >= hi-lo-1 {
+		return lo, hi
+	}
+	return w[k], w[k-1] + 360
+}
+
+// DataBounds reads every shape of r not deleted and returns their geographic bounds,
+// with the longitudes of LongitudeRange, so data across the antimeridian
+// gets a narrow extent rather than nearly the whole globe. To map it, set
+// Config.CentralMeridian to the middle of the range and subtract that from
+// the bounds.
+func DataBounds(r *shp.Reader) (Geometry, error) {
+	var lons []float64
+	var g Geometry
+	first := true
+	deleted := readerdeleted(r)
+	for r.Next() {
+		n, s := r.Shape()
+		if isdeleted(deleted, n) {
+			continue
+		}
+		for _, p := range shapepoints(s) {
+			lons = append(lons, p.X)
+			if first || p.Y < g.Latmin {
+				g.Latmin = p.Y
+			}
+			if first || p.Y > g.Latmax {
+				g.Latmax = p.Y
+			}
+			first = false
+		}
+	}
+	g.Longmin, g.Longmax = LongitudeRange(lons)
+	return g, r.Err()
+}
@@ -0,0 +1,108 @@
+package shpdeck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// box is a closed ring around a rectangle of longitude and latitude
+func box(lon0, lat0, lon1, lat1 float64) []shp.Point {
+	return ring(shp.Point{X: lon0, Y: lat0}, shp.Point{X: lon0, Y: lat1}, shp.Point{X: lon1, Y: lat1}, shp.Point{X: lon1, Y: lat0})
+}
+
+func TestRecenterPoint(t *testing.T) {
+	c := Config{CentralMeridian: 150}
+	tests := []struct {
+		lon, want float64
+	}{
+		{150, 0},
+		{180, 30},
+		{-170, 40},
+		{-30, -180}, // the seam
+		{0, -150},
+		{120, -30},
+	}
+	for _, tt := range tests {
+		if got := c.recenterpoint(shp.Point{X: tt.lon}).X; math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("longitude %v recenters to %v, want %v", tt.lon, got, tt.want)
+		}
+	}
+}
+
+// TestRecenter150E centers on 150°E, where the Pacific is whole and
+// the seam runs down the Atlantic at 30°W
+func TestRecenter150E(t *testing.T) {
+	c := Config{CentralMeridian: 150}
+	tests := []struct {
+		name   string
+		part   []shp.Point
+		closed bool
+		pieces int
+		lo, hi float64 // longitudes of the pieces, once recentered
+	}{
+		{"across the antimeridian", box(170, -20, -170, -10), true, 1, 20, 40},
+		{"across the seam", box(-35, 0, -25, 10), true, 2, -180, 180},
+		{"line across the seam", []shp.Point{{X: -40, Y: 0}, {X: -20, Y: 0}}, false, 2, -180, 180},
+		{"away from the seam", box(100, 0, 110, 10), true, 1, -50, -40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pieces := c.recenter(tt.part, tt.closed, false)
+			if len(pieces) != tt.pieces {
+				t.Fatalf("%d pieces, want %d: %v", len(pieces), tt.pieces, pieces)
+			}
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, piece := range pieces {
+				for _, p := range piece {
+					lo, hi = min(lo, p.X), max(hi, p.X)
+				}
+			}
+			if math.Abs(lo-tt.lo) > 1e-9 || math.Abs(hi-tt.hi) > 1e-9 {
+				t.Errorf("longitudes %v..%v, want %v..%v", lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestLongitudeRange(t *testing.T) {
+	global := make([]float64, 36)
+	for i := range global {
+		global[i] = float64(10*i - 180)
+	}
+	tests := []struct {
+		name   string
+		lons   []float64
+		lo, hi float64
+	}{
+		{"Fiji", []float64{177, 179.5, -179.8, -178}, 177, 182},
+		{"on the antimeridian", []float64{179, -179, 180}, 179, 181},
+		{"Europe", []float64{10, -5, 20, 15}, -5, 20},
+		{"nearly global", global, -180, 170},
+		{"one longitude", []float64{42}, 42, 42},
+		{"none", nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if lo, hi := LongitudeRange(tt.lons); lo != tt.lo || hi != tt.hi {
+				t.Errorf("LongitudeRange = %v, %v, want %v, %v", lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestDataBoundsAntimeridian(t *testing.T) {
+	shapes := []shp.Shape{
+		shp.NewPolyLine([][]shp.Point{box(178, -18, 180, -16)}),
+		shp.NewPolyLine([][]shp.Point{box(-180, -19, -179, -17)}),
+	}
+	g, err := DataBounds(openShapefile(t, writeShapefile(t, shp.POLYLINE, shapes, nil, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Geometry{Longmin: 178, Longmax: 181, Latmin: -19, Latmax: -16}
+	if g != want {
+		t.Errorf("DataBounds = %+v, want %+v", g, want)
+	}
+}
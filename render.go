@@ -0,0 +1,310 @@
+package shpdeck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Stats describes what a render produced
+type Stats struct {
+	Features int     // records rendered
+	Sampled  int     // records the Config.MaxFeatures sample was drawn from
+	Skipped  int     // features or parts skipped, for any reason (see Config.Logger)
+	Deleted  int     // records skipped because the DBF marks them deleted
+	Failed   []int   // records that could not be parsed, with Config.SkipCorrupt
+	Overlaps int     // overlapping pairs of polygons, with Config.WarnOverlaps
+	Vertices int     // coordinates written
+	Bytes    int64   // bytes written
+	Bounds   shp.Box // geographic extent of the rendered records
+
+	// Occupancy counts the rendered records over a coarse grid of the screen
+	// box, rows from Ymin, for LeastBusyCorner
+	Occupancy [occupancycells][occupancycells]int
+}
+
+// countWriter counts the bytes written through it
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// RenderFile renders every record of the named shapefile,
+// except those the DBF table marks as deleted. With Config.SkipCorrupt,
+// records that cannot be parsed are skipped and listed in Stats.Failed.
+func RenderFile(dest io.Writer, filename string, g Geometry, c Config) (Stats, error) {
+	if c.Encoding == "" {
+		c.Encoding = CodePage(filename)
+	}
+	if c.SkipCorrupt {
+		return renderTolerant(dest, filename, g, c)
+	}
+	r, err := Open(filename)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer r.Close()
+	return RenderReaders(dest, []*shp.Reader{r}, g, c)
+}
+
+// RenderReaders renders every record of each reader in turn, with the same style.
+// With Config.ClipToPrevious, each layer after the first is clipped to the
+// polygons of the layer before it.
+func RenderReaders(dest io.Writer, readers []*shp.Reader, g Geometry, c Config) (Stats, error) {
+	var st Stats
+	cw := &countWriter{w: dest}
+	boundscomment(cw, g, c)
+	var footprint [][]shp.Point
+	for i, r := range readers {
+		if !c.ClipToPrevious {
+			if err := renderloop(cw, r, g, c, &st, nil); err != nil {
+				st.Bytes = cw.n
+				return st, err
+			}
+			continue
+		}
+		lc := c
+		if i > 0 {
+			if len(footprint) == 0 {
+				continue // nothing to draw within
+			}
+			lc.ClipPolygon, lc.ClipMask = nil, footprint
+		}
+		t := &maskrecords{records: r, deleted: readerdeleted(r)}
+		lc.deleted = t.deleted
+		err := renderloop(cw, t, g, lc, &st, nil)
+		footprint = t.mask
+		if err != nil {
+			st.Bytes = cw.n
+			return st, err
+		}
+	}
+	st.Bytes = cw.n
+	return st, nil
+}
+
+// maskrecords collects a clip mask of the polygons read through it
+// that are not deleted
+type maskrecords struct {
+	records
+	deleted []bool
+	mask    [][]shp.Point
+}
+
+func (m *maskrecords) Shape() (int, shp.Shape) {
+	n, s := m.records.Shape()
+	if poly, ok := s.(*shp.Polygon); ok && !isdeleted(m.deleted, n) {
+		m.mask = append(m.mask, polygonmask(poly)...)
+	}
+	return n, s
+}
+
+// RenderShapes renders shapes already read into memory, in order, as
+// RenderFile renders the records of a file. Logger messages number each
+// shape by its index.
+func RenderShapes(dest io.Writer, shapes []shp.Shape, g Geometry, c Config) error {
+	b := &recordBuffer{recs: make([]record, len(shapes))}
+	for i, s := range shapes {
+		b.recs[i] = record{row: i, shape: s}
+	}
+	boundscomment(dest, g, c)
+	return renderloop(dest, b, g, c, nil, nil)
+}
+
+// boundscomment records the mapping in a leading comment, when Config.BoundsComment is set,
+// so that consumers of a fragment can reconstruct the coordinate space;
+// the geographic bounds are in projected units when there is a Projection
+func boundscomment(w io.Writer, g Geometry, c Config) {
+	if !c.BoundsComment {
+		return
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	projection := ""
+	switch p := c.Projection.(type) {
+	case nil:
+	case fmt.Stringer:
+		projection = fmt.Sprintf(" projection=\"%s\"", commentesc(p.String()))
+	default:
+		projection = fmt.Sprintf(" projection=\"%s\"", commentesc(fmt.Sprintf("%T", p)))
+	}
+	fmt.Fprintf(w, "<!-- shpdeck screen x=%s..%s y=%s..%s geographic longitude=%s..%s latitude=%s..%s%s -->\n",
+		f(g.Xmin), f(g.Xmax), f(g.Ymin), f(g.Ymax), f(g.Longmin), f(g.Longmax), f(g.Latmin), f(g.Latmax), projection)
+}
+
+// renderloop renders each record of r, except those its DBF marks deleted.
+// If style is not nil it adjusts the Config for each record.
+// Stats are accumulated into st when it is not nil.
+func renderloop(dest io.Writer, r records, g Geometry, c Config, st *Stats, style func(row int, c Config) Config) error {
+	return renderoutputs([]*output{{w: dest, format: c.Format, st: st}}, r, g, c, style)
+}
+
+// output is one destination of renderoutputs, with its own format,
+// statistics and element budget
+type output struct {
+	w      io.Writer
+	format Format
+	st     *Stats
+	ew     *errWriter // if not nil, output stops at its first write error
+
+	budget   int
+	overlaps *overlapcheck
+	rendered int
+}
+
+// renderoutputs renders the records of r to each output, reading and
+// ordering them once, as renderloop renders them to one
+func renderoutputs(outs []*output, r records, g Geometry, c Config, style func(row int, c Config) Config) error {
+	if len(outs) == 1 {
+		c.stats = outs[0].st
+	}
+	if c.deleted == nil {
+		c.deleted = readerdeleted(attributes(r))
+	}
+	var comments *featurecomments
+	if len(c.CommentFields) > 0 {
+		comments = newfeaturecomments(attributes(r), c.CommentFields, c.Encoding)
+	}
+	bearings, bearing := attributes(r), -1
+	if c.BearingField != "" && bearings != nil {
+		bearing = fieldIndex(bearings, c.BearingField)
+	}
+	if c.MaxFeatures > 0 {
+		var total int
+		r, total = sampled(r, c.MaxFeatures, c.Seed)
+		for _, o := range outs {
+			if o.st != nil {
+				o.st.Sampled += total
+			}
+		}
+	}
+	if c.SortBy != "" {
+		r = keyordered(r, c.SortBy, c.ParseValue)
+	}
+	if c.ZOrder != "" {
+		r = zordered(r, c.ZOrder, c.ParseValue)
+	} else if c.MaxElements > 0 {
+		r = sizeordered(r)
+	}
+	for _, o := range outs {
+		o.budget = c.MaxElements
+		if c.WarnOverlaps {
+			o.overlaps = &overlapcheck{}
+		}
+	}
+	for r.Next() {
+		n, s := r.Shape()
+		fc := c
+		fc.record = n
+		deleted := isdeleted(c.deleted, n)
+		if !deleted {
+			if bearing >= 0 {
+				fc.bearing, _ = fieldValue(bearings, n, bearing, c.ParseValue)
+			}
+			if style != nil {
+				fc = style(n, fc)
+			}
+		}
+		for _, o := range outs {
+			if o.ew != nil && o.ew.err != nil {
+				continue
+			}
+			oc := fc
+			oc.Format, oc.stats = o.format, o.st
+			if deleted {
+				if o.st != nil {
+					o.st.Deleted++
+				}
+				oc.skip("deleted in the DBF")
+				continue
+			}
+			o.draw(n, s, g, oc, comments)
+		}
+	}
+	if c.FlushEvery > 0 {
+		for _, o := range outs {
+			flush(o.w)
+		}
+	}
+	return r.Err()
+}
+
+// draw renders record n to the output, within its element budget
+func (o *output) draw(n int, s shp.Shape, g Geometry, c Config, comments *featurecomments) {
+	if c.MaxElements > 0 {
+		var buf bytes.Buffer
+		var fst Stats
+		c.stats = &fst
+		if comments != nil {
+			comments.write(&buf, n)
+		}
+		RenderShape(&buf, s, g, c)
+		c.stats = o.st
+		k := elements(buf.Bytes())
+		if k > o.budget {
+			if c.reporting() {
+				c.skip(fmt.Sprintf("%d elements are over the remaining budget of %d", k, o.budget))
+			}
+			return
+		}
+		o.budget -= k
+		if o.st != nil {
+			o.st.Skipped += fst.Skipped
+			o.st.Vertices += fst.Vertices
+		}
+		o.w.Write(buf.Bytes())
+	} else {
+		if comments != nil {
+			comments.write(o.w, n)
+		}
+		RenderShape(o.w, s, g, c)
+	}
+	if o.overlaps != nil {
+		o.overlaps.check(n, s, c)
+	}
+	if o.st != nil {
+		o.st.count(s)
+		if s != nil {
+			o.st.occupy(s, g, c)
+		}
+	}
+	if o.rendered++; c.FlushEvery > 0 && o.rendered%c.FlushEvery == 0 {
+		flush(o.w)
+	}
+}
+
+// flush flushes a writer that buffers, such as an http.ResponseWriter
+// (http.Flusher) or a *bufio.Writer, looking through the package's own wrappers
+func flush(w io.Writer) {
+	switch f := w.(type) {
+	case *countWriter:
+		flush(f.w)
+	case *errWriter:
+		flush(f.w)
+	case interface{ Flush() error }:
+		f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+}
+
+// count adds a rendered record to the statistics
+func (st *Stats) count(s shp.Shape) {
+	if s == nil {
+		return
+	}
+	if st.Features == 0 {
+		st.Bounds = s.BBox()
+	} else {
+		st.Bounds.Extend(s.BBox())
+	}
+	st.Features++
+}
@@ -0,0 +1,194 @@
+package shpdeck
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// flat is a projection without a name
+type flat struct{}
+
+func (flat) Project(lon, lat float64) (float64, float64) { return lon, lat }
+
+func TestBoundsComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection Projection
+		want       string
+	}{
+		{"none", nil, "latitude=0..10 -->"},
+		{"named", AzimuthalEquidistant{Lon0: -100, Lat0: 45.5}, `latitude=0..10 projection="azimuthal equidistant lon0=-100 lat0=45.5" -->`},
+		{"unnamed", flat{}, `projection="shpdeck.flat" -->`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.BoundsComment = true
+			c.Projection = tt.projection
+			var b strings.Builder
+			boundscomment(&b, unit, c)
+			if !strings.HasPrefix(b.String(), "<!-- shpdeck screen x=0..100 y=0..100 geographic longitude=0..10 ") || !strings.HasSuffix(b.String(), tt.want+"\n") {
+				t.Errorf("comment %q, want it to end %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+// polygonx returns the first x coordinate of each polygon in markup, in order
+func polygonx(tb testing.TB, markup string) []float64 {
+	tb.Helper()
+	var xs []float64
+	for _, line := range strings.Split(markup, "\n") {
+		if !strings.HasPrefix(line, "<polygon ") {
+			continue
+		}
+		_, rest, _ := strings.Cut(line, ` xc="`)
+		var x float64
+		if _, err := fmt.Sscanf(rest, "%g", &x); err != nil {
+			tb.Fatalf("%s: %v", line, err)
+		}
+		xs = append(xs, x)
+	}
+	return xs
+}
+
+// TestClipToPreviousOrder draws the first, clipping layer in the order of
+// SortBy and then ZOrder, whatever else renderloop is asked to do
+func TestClipToPreviousOrder(t *testing.T) {
+	shapes := []shp.Shape{square(0, 0, 3), square(4, 0, 1), square(6, 0, 2)}
+	fields := []shp.Field{shp.StringField("Z", 4), shp.StringField("S", 4)}
+	rows := [][]any{{"2", "b"}, {"1", "c"}, {"2", "a"}}
+	tests := []struct {
+		name string
+		set  func(*Config)
+	}{
+		{"ordered", func(*Config) {}},
+		{"MaxElements", func(c *Config) { c.MaxElements = 100 }},
+		{"MaxFeatures", func(c *Config) { c.MaxFeatures = 3 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.ClipToPrevious = true
+			c.ZOrder, c.SortBy = "Z", "S"
+			tt.set(&c)
+			r := openShapefile(t, writeShapefile(t, shp.POLYGON, shapes, fields, rows))
+			var b strings.Builder
+			if _, err := RenderReaders(&b, []*shp.Reader{r}, unit, c); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := polygonx(t, b.String()), []float64{40, 60, 0}; !slices.Equal(got, want) {
+				t.Errorf("polygons at x %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestZOrder draws records ascending by the ZOrder field, keeping file
+// order among equal values and sorting values that do not parse as zero
+func TestZOrder(t *testing.T) {
+	zs := []string{"2", "1", "", "2", "-1", "n/a"}
+	shapes := make([]shp.Shape, len(zs))
+	rows := make([][]any, len(zs))
+	for i, z := range zs {
+		shapes[i] = square(float64(i), 0, 1)
+		rows[i] = []any{z}
+	}
+	filename := writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("Z", 4)}, rows)
+	for _, field := range []string{"Z", "NONE"} {
+		c := NewConfig("polygon", "red", 0)
+		c.ZOrder = field
+		var b strings.Builder
+		if _, err := RenderReaders(&b, []*shp.Reader{openShapefile(t, filename)}, unit, c); err != nil {
+			t.Fatal(err)
+		}
+		want := []float64{40, 20, 50, 10, 0, 30}
+		if field == "NONE" {
+			want = []float64{0, 10, 20, 30, 40, 50}
+		}
+		if got := polygonx(t, b.String()); !slices.Equal(got, want) {
+			t.Errorf("ZOrder %s: polygons at x %v, want %v", field, got, want)
+		}
+	}
+}
+
+// TestMaxElements draws records largest first, or in ZOrder, skipping those
+// over the remaining budget and going on with smaller ones that fit
+func TestMaxElements(t *testing.T) {
+	parts := func(a, b *shp.Polygon) shp.Shape {
+		p := shp.Polygon(*shp.NewPolyLine([][]shp.Point{a.Points, b.Points}))
+		return &p
+	}
+	shapes := []shp.Shape{
+		parts(square(0, 0, 1), square(4, 4, 1)),     // two elements, box area 25
+		square(6, 0, 3),                             // area 9
+		parts(square(1, 6, 1), square(2.5, 7.5, 1)), // two elements, area 6.25
+		square(9, 9, 1),                             // area 1
+	}
+	rows := [][]any{{"3"}, {"1"}, {"0"}, {"2"}}
+	filename := writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("Z", 4)}, rows)
+	tests := []struct {
+		name   string
+		zorder string
+		want   []float64
+	}{
+		{"largest first", "", []float64{0, 40, 60, 90}},
+		{"ZOrder", "Z", []float64{10, 25, 60, 90}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.MaxElements = 4
+			c.ZOrder = tt.zorder
+			var b strings.Builder
+			st, err := RenderReaders(&b, []*shp.Reader{openShapefile(t, filename)}, unit, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := polygonx(t, b.String()); !slices.Equal(got, tt.want) {
+				t.Errorf("polygons at x %v, want %v", got, tt.want)
+			}
+			if st.Skipped != 1 {
+				t.Errorf("%d skipped, want 1", st.Skipped)
+			}
+		})
+	}
+}
+
+// TestSortBy draws records ascending by the SortBy key, as numbers when
+// every key parses and as text otherwise, keeping file order among ties
+func TestSortBy(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []float64
+	}{
+		{"numbers", []string{"10", "9", "2", "9"}, []float64{20, 10, 30, 0}},
+		{"text", []string{"10", "9", "b", "9"}, []float64{0, 10, 30, 20}},
+		{"names", []string{"delta", "alpha", "charlie", "alpha"}, []float64{10, 30, 20, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := make([]shp.Shape, len(tt.keys))
+			rows := make([][]any, len(tt.keys))
+			for i, k := range tt.keys {
+				shapes[i] = square(float64(i), 0, 1)
+				rows[i] = []any{k}
+			}
+			r := openShapefile(t, writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("K", 8)}, rows))
+			c := NewConfig("polygon", "red", 0)
+			c.SortBy = "K"
+			var b strings.Builder
+			if _, err := RenderReaders(&b, []*shp.Reader{r}, unit, c); err != nil {
+				t.Fatal(err)
+			}
+			if got := polygonx(t, b.String()); !slices.Equal(got, tt.want) {
+				t.Errorf("polygons at x %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package shpdeck
+
+import (
+	"slices"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Winding says how the outer rings of polygon input are wound
+type Winding int
+
+const (
+	// WindingCW has clockwise outer rings and counter-clockwise holes, per the shapefile specification
+	WindingCW Winding = iota
+	// WindingCCW has counter-clockwise outer rings and clockwise holes, as in GeoJSON
+	WindingCCW
+	// WindingAuto ignores the winding and finds holes by nesting:
+	// a ring inside an odd number of other rings is a hole
+	WindingAuto
+)
+
+// normalizewinding rewinds rings so that outer rings are clockwise and holes counter-clockwise
+func normalizewinding(rings [][]shp.Point, w Winding) [][]shp.Point {
+	switch w {
+	case WindingCCW:
+		out := make([][]shp.Point, len(rings))
+		for i, ring := range rings {
+			out[i] = reversed(ring)
+		}
+		return out
+	case WindingAuto:
+		out := make([][]shp.Point, len(rings))
+		for i, ring := range rings {
+			depth := 0
+			for j, other := range rings {
+				if i != j && ringinring(ring, other) {
+					depth++
+				}
+			}
+			hole := depth%2 == 1
+			if (signedArea(ring) > 0) != hole {
+				ring = reversed(ring)
+			}
+			out[i] = ring
+		}
+		return out
+	}
+	return rings
+}
+
+// reversed returns a reversed copy of a ring
+func reversed(ring []shp.Point) []shp.Point {
+	r := slices.Clone(ring)
+	slices.Reverse(r)
+	return r
+}
+
+// ringgroups sorts the rings of a polygon record into groups, each an outer
+// ring followed by its holes. Per the shapefile specification outer rings are
+// clockwise and holes counter-clockwise. A counter-clockwise ring that is not
+// inside an earlier outer ring is treated as an outer ring of its own.
+func ringgroups(rings [][]shp.Point) [][][]shp.Point {
+	var groups [][][]shp.Point
+	for _, ring := range rings {
+		if signedArea(ring) < 0 {
+			groups = append(groups, [][]shp.Point{ring})
+			continue
+		}
+		owner := -1
+		for k := len(groups) - 1; k >= 0; k-- {
+			if ringinring(ring, groups[k][0]) {
+				owner = k
+				break
+			}
+		}
+		if owner < 0 {
+			groups = append(groups, [][]shp.Point{ring})
+			continue
+		}
+		groups[owner] = append(groups[owner], ring)
+	}
+	return groups
+}
+
+// ringinring reports whether the first vertex of ring a is inside ring b
+func ringinring(a, b []shp.Point) bool {
+	p := a[0]
+	in := false
+	n := len(b)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		if (b[i].Y > p.Y) != (b[j].Y > p.Y) && p.X < (b[j].X-b[i].X)*(p.Y-b[i].Y)/(b[j].Y-b[i].Y)+b[i].X {
+			in = !in
+		}
+	}
+	return in
+}
+
+// bridgeholes joins the holes of a group to its outer ring, making a single
+// ring that walks out to each hole along a zero-width cut and back again.
+// Filled with the even-odd or non-zero rule, the holes stay empty.
+func bridgeholes(group [][]shp.Point) []shp.Point {
+	out := append([]shp.Point(nil), group[0]...)
+	for _, hole := range group[1:] {
+		// connect the closest pair of vertices
+		bi, bj, best := 0, 0, -1.0
+		for i, p := range out {
+			for j, q := range hole {
+				d := (p.X-q.X)*(p.X-q.X) + (p.Y-q.Y)*(p.Y-q.Y)
+				if best < 0 || d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		merged := make([]shp.Point, 0, len(out)+len(hole)+2)
+		merged = append(merged, out[:bi+1]...)
+		merged = append(merged, hole[bj:]...)
+		merged = append(merged, hole[:bj+1]...)
+		merged = append(merged, out[bi:]...)
+		out = merged
+	}
+	return out
+}
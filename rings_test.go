@@ -0,0 +1,105 @@
+package shpdeck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// ring makes a closed ring through the points, in the order given
+func ring(pts ...shp.Point) []shp.Point {
+	return append(pts, pts[0])
+}
+
+var (
+	// clockwise outer rings, per the shapefile specification
+	outerA = ring(shp.Point{X: 0, Y: 0}, shp.Point{X: 0, Y: 4}, shp.Point{X: 4, Y: 4}, shp.Point{X: 4, Y: 0})
+	outerB = ring(shp.Point{X: 6, Y: 0}, shp.Point{X: 6, Y: 4}, shp.Point{X: 10, Y: 4}, shp.Point{X: 10, Y: 0})
+	// counter-clockwise holes
+	holeA = ring(shp.Point{X: 1, Y: 1}, shp.Point{X: 3, Y: 1}, shp.Point{X: 3, Y: 3}, shp.Point{X: 1, Y: 3})
+	holeB = ring(shp.Point{X: 7, Y: 1}, shp.Point{X: 9, Y: 1}, shp.Point{X: 9, Y: 3}, shp.Point{X: 7, Y: 3})
+)
+
+func TestRingGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		rings   [][]shp.Point
+		winding Winding
+		sizes   []int // rings in each group, the outer ring first
+	}{
+		{"two outers and a hole", [][]shp.Point{outerA, holeA, outerB}, WindingCW, []int{2, 1}},
+		{"hole of the second outer", [][]shp.Point{outerA, outerB, holeB}, WindingCW, []int{1, 2}},
+		{"holes listed after both outers", [][]shp.Point{outerA, outerB, holeA, holeB}, WindingCW, []int{2, 2}},
+		{"counter-clockwise winding", [][]shp.Point{reversed(outerA), reversed(holeA), reversed(outerB)}, WindingCCW, []int{2, 1}},
+		{"winding ignored", [][]shp.Point{reversed(outerA), holeA, outerB}, WindingAuto, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			for _, group := range ringgroups(normalizewinding(tt.rings, tt.winding)) {
+				sizes = append(sizes, len(group))
+			}
+			if !slices.Equal(sizes, tt.sizes) {
+				t.Errorf("groups of %v rings, want %v", sizes, tt.sizes)
+			}
+		})
+	}
+}
+
+// TestMultipolygon draws a record of two polygons, the first with a hole,
+// as two filled polygons with the hole cut out of the first
+func TestMultipolygon(t *testing.T) {
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, holeA, outerB}))
+	var b strings.Builder
+	PolygonCoords(&b, &poly, unit, NewConfig("polygon", "red", 0))
+	polygons := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(polygons) != 2 {
+		t.Fatalf("%d polygons, want 2:\n%s", len(polygons), b.String())
+	}
+	// the hole, from x 10 to 30 on screen, is bridged into the first polygon
+	xs := func(polygon string) []string {
+		_, rest, _ := strings.Cut(polygon, `xc="`)
+		xc, _, _ := strings.Cut(rest, `"`)
+		return strings.Fields(xc)
+	}
+	if x := xs(polygons[0]); !slices.Contains(x, "10.00000") || !slices.Contains(x, "30.00000") {
+		t.Errorf("the hole is not in the first polygon: %s", polygons[0])
+	}
+	if x := xs(polygons[1]); slices.Contains(x, "10.00000") || len(x) != 6 {
+		t.Errorf("the second polygon is not a plain square: %s", polygons[1])
+	}
+}
+
+// TestWindingOutput checks that a record and its rewound copy render
+// identically once the winding is known, or found by nesting
+func TestWindingOutput(t *testing.T) {
+	cw := [][]shp.Point{outerA, holeA, outerB}
+	ccw := [][]shp.Point{reversed(outerA), reversed(holeA), reversed(outerB)}
+	render := func(rings [][]shp.Point, w Winding) string {
+		poly := shp.Polygon(*shp.NewPolyLine(rings))
+		c := NewConfig("polygon", "red", 0)
+		c.AssumeWinding = w
+		var b strings.Builder
+		PolygonCoords(&b, &poly, unit, c)
+		return b.String()
+	}
+	want := render(cw, WindingCW)
+	tests := []struct {
+		name    string
+		rings   [][]shp.Point
+		winding Winding
+	}{
+		{"counter-clockwise", ccw, WindingCCW},
+		{"clockwise found", cw, WindingAuto},
+		{"counter-clockwise found", ccw, WindingAuto},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(tt.rings, tt.winding); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
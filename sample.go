@@ -0,0 +1,27 @@
+package shpdeck
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// sampled reads every record of r and keeps a uniform random sample of n of
+// them by reservoir sampling, seeded so that the same seed keeps the same
+// records. The sample keeps file order. It also returns how many records were read.
+func sampled(r records, n int, seed uint64) (records, int) {
+	b := &recordBuffer{reader: attributes(r)}
+	rng := rand.New(rand.NewPCG(seed, seed))
+	total := 0
+	for r.Next() {
+		row, s := r.Shape()
+		total++
+		if len(b.recs) < n {
+			b.recs = append(b.recs, record{row: row, shape: s})
+		} else if k := rng.IntN(total); k < n {
+			b.recs[k] = record{row: row, shape: s}
+		}
+	}
+	b.err = r.Err()
+	slices.SortFunc(b.recs, func(x, y record) int { return x.row - y.row })
+	return b, total
+}
@@ -3,6 +3,9 @@ package shpdeck
 import (
 	"fmt"
 	"io"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/jonas-p/go-shp"
@@ -16,8 +19,189 @@ type Config struct {
 	maptype   string
 	color     string
 	shapesize float64
+	// ClipPolygon, when it has at least three points, restricts output
+	// to the inside of a convex polygon in geographic coordinates
+	ClipPolygon []shp.Point
+	// ClipMask restricts output to the union of convex pieces, such as
+	// the triangles made by Triangulate, so that any shape can be a clip region
+	ClipMask [][]shp.Point
+	// Logger, if set, is told about each feature or part that is skipped and why
+	Logger func(string)
+	// FillPattern overlays polygons with a pattern (PatternHatch, PatternDots, ...)
+	// drawn in PatternColor (default black), PatternSpacing (default 1) apart
+	FillPattern    string
+	PatternColor   string
+	PatternSpacing float64
+	// GlowRings, if positive, draws each dot as that many concentric rings
+	// fading out toward twice the dot size; GlowFalloff is the exponent of the
+	// fade (default 2)
+	GlowRings   int
+	GlowFalloff float64
+	// Format selects the output markup, Deck by default
+	Format Format
+	// WarpFunc, if set, moves every mapped screen coordinate before it is written,
+	// for cartograms, fisheyes and other distortions. It runs once per vertex,
+	// so it must be cheap.
+	WarpFunc func(x, y float64) (float64, float64)
+	// BoundsComment starts rendered output with a comment giving the
+	// screen box and geographic bounds used, and the Projection if any
+	BoundsComment bool
+	// MinFeatureSize, if positive, draws polygons whose mapped extent is
+	// smaller than this as a dot of this size, so small features stay visible
+	MinFeatureSize float64
+	// SkipCorrupt makes RenderFile step over records that fail to parse
+	// rather than stop at the first one
+	SkipCorrupt bool
+	// AssumeWinding says how polygon rings are wound, to tell holes from outer rings
+	AssumeWinding Winding
+	// Shadow, when its Color is set, draws an offset copy beneath every
+	// feature; this doubles the number of elements written
+	Shadow Shadow
+	// Smooth, if 2 or more, draws rings and lines as curves through their
+	// vertices with this many segments per edge; straight edges are the default
+	Smooth int
+	// Text overrides the style of labels and other text
+	Text TextStyle
+	// Flow styles the arcs of RenderFlows
+	Flow Flow
+	// Marker is the shape drawn at points: MarkerDot, MarkerTriangle or
+	// MarkerArrow. BearingField names a numeric DBF field holding a heading
+	// in degrees clockwise from north, such as a wind direction, that
+	// rotates the marker of each record.
+	Marker       string
+	BearingField string
+	// Leader joins labels that RenderLabels moves away from their features
+	Leader Leader
+	// Outline outlines the land of RenderLandSea, when its Color is set
+	Outline Stroke
+	// AbbreviateLabels shortens labels that RenderLabels estimates are wider
+	// than their feature's mapped bounding box: to their entry in
+	// Abbreviations, such as state postal codes, or else by truncating them
+	// with an ellipsis
+	AbbreviateLabels bool
+	Abbreviations    map[string]string
+	// Locale formats the numbers in labels and legends
+	Locale Locale
+	// Projection, if set, projects coordinates before they are mapped,
+	// such as AzimuthalEquidistant
+	Projection Projection
+	// ScaleX and ScaleY map longitude and latitude, or for non-geographic
+	// data x and y, on a Linear (default) or Log scale
+	ScaleX, ScaleY Scale
+	// GroupParts keeps the parts of each multi-part feature together:
+	// SVG polygons become one path of all their rings, filled even-odd, and
+	// since deck has no multi-path polygon, deck output (and SVG lines) wrap
+	// the parts in a group named for the record
+	GroupParts bool
+	// DebugParts, for diagnosing multi-part shapes, draws each part on its
+	// own in a color of the "set1" palette by part index, holes included
+	DebugParts bool
+	// DegenerateDots draws the polyline parts of a single point as dots,
+	// which otherwise draw nothing
+	DegenerateDots bool
+	// Precision is the number of decimal places of coordinates. Zero keeps
+	// the defaults, 5 for polygons and text and 7 for lines, dots and curves,
+	// so whole numbers, with no decimal point, are written with IntegerPrecision
+	Precision int
+	// CanvasWidth, if > 0, is the width in pixels that the output will be
+	// drawn at, and chooses the precision instead: the fewest decimal places p
+	// for which one step of 10^-p screen units is no more than a pixel,
+	// p = ceil(log10(CanvasWidth / width)), and at least 0, where the width of
+	// the screen box is |Xmax - Xmin|, or 100 with Relative
+	CanvasWidth float64
+	// BufferInset, if > 0, shrinks filled polygons by this many screen units
+	// before they are drawn, so the fill stops short of the border; draw the
+	// outline as a separate "line" layer. See inset for the limits on
+	// concave shapes.
+	BufferInset float64
+	// MinSegment, if > 0, drops points that lie closer than this on screen
+	// to the previous point, removing sub-pixel jitter from noisy data
+	MinSegment float64
+	// ClipToScreen clips mapped shapes to the Geometry screen box, so that
+	// nothing draws outside it, as in panels of small multiples
+	ClipToScreen bool
+	// Relative writes coordinates as percentages of the Geometry screen box,
+	// so that (Xmin, Ymin) is 0,0 and (Xmax, Ymax) is 100,100. Sizes and
+	// widths are unchanged.
+	Relative bool
+	// CentralMeridian recenters the map on a longitude, such as 150 for a
+	// Pacific-centered world map: longitudes are shifted to lie within 180
+	// degrees of it, and shapes crossing the new seam are split there.
+	// Geometry and clip longitudes are then relative to the central meridian.
+	CentralMeridian float64
+	// WarnOverlaps reports each polygon that overlaps one drawn before it
+	// in the same render to the Logger, and counts them in Stats.Overlaps.
+	// Deck stacks the opacity of overlapping shapes, so overlaps of
+	// translucent polygons draw darker; nothing is merged or blended.
+	WarnOverlaps bool
+	// MaxVerticesPerFeature, if > 0, skips polygons, polylines and
+	// multipoints with more points than this, as a guard against runaway
+	// output from malformed or untrusted files
+	MaxVerticesPerFeature int
+	// MaxElements, if > 0, bounds the output to that many elements: records
+	// are drawn largest first (by bounding box, unless ZOrder sets the order)
+	// and those that would go over the budget are skipped, and logged
+	MaxElements int
+	// MaxFeatures, if > 0, draws a random sample of at most this many
+	// records, the same for the same Seed, for previews of huge layers
+	MaxFeatures int
+	// Seed seeds random placement, as in RenderDotDensity, and sampling
+	Seed uint64
+	// ClipToPrevious clips each layer of RenderReaders after the first to the
+	// footprint of the polygons in the layer before it, in place of
+	// ClipPolygon and ClipMask. The footprint is triangulated, and each
+	// feature is clipped against every triangle that makes it up, so the cost
+	// grows with the detail of both layers.
+	ClipToPrevious bool
+	// TableRows limits the rows of RenderAttributeTable, and TableWidth
+	// the characters in each cell; zero means no limit
+	TableRows  int
+	TableWidth int
+	// Simplify, if > 0, simplifies every part with this tolerance in source
+	// units, such as ToleranceForZoom(6) for geographic data (see Simplify)
+	Simplify float64
+	// Encoding is the code page of DBF text, for labels, tables and comments
+	// (see DecodeText); RenderFile reads it with CodePage when it is empty
+	Encoding string
+	// CommentFields names DBF fields written as key="value" pairs in a
+	// comment before each feature's markup, for tools that attach tooltips
+	// or other metadata; renderers ignore the comments
+	CommentFields []string
+	// ZOrder names a numeric DBF field; when set, records are read in full
+	// and drawn in ascending order of it, so higher values draw on top.
+	// Records with equal values keep their file order.
+	ZOrder string
+	// SortBy names a DBF field to draw records in order of, ascending, so
+	// that output does not depend on the order of records in the file: by
+	// number if every value is one, else by text. Records with equal keys
+	// keep file order, and ZOrder, if set, then orders by its field, keeping
+	// SortBy order among its ties.
+	SortBy string
+	// ParseValue, if not nil, replaces ParseValue in reading the numeric
+	// fields of choropleths, dot density, ZOrder and BearingField, for values
+	// such as "$1,200" that strconv cannot parse
+	ParseValue func(raw string) (float64, bool)
+	// FlushEvery, if positive, flushes the destination after every
+	// FlushEvery features so output can stream, for example over HTTP.
+	// It takes effect only when the destination implements http.Flusher
+	// or has a Flush() error method like *bufio.Writer.
+	FlushEvery int
+	// GreatCircleSteps, if positive, draws each segment of a polyline as the
+	// great circle route between its ends, in this many steps; for lines in
+	// longitude and latitude such as flight paths
+	GreatCircleSteps int
+	// Parts, if not empty, restricts multi-part shapes to the listed part indices
+	Parts   []int
+	record  int     // index of the record being rendered, for Logger
+	bearing float64 // the record's Config.BearingField
+	screen  float64 // width of the screen box in output units, for Config.CanvasWidth
+	stats   *Stats  // render statistics, when collected
+	deleted []bool  // records the DBF marks deleted
 }
 
+// IntegerPrecision is the Config.Precision that writes coordinates as whole numbers
+const IntegerPrecision = -1
+
 // types used from go-shp
 type Point shp.Point
 type Polygon shp.Polygon
@@ -25,8 +209,9 @@ type PolyLine shp.PolyLine
 type MultiPoint shp.MultiPoint
 
 const (
-	linefmt = "<line xp1=\"%.7f\" yp1=\"%.7f\" xp2=\"%.7f\" yp2=\"%.7f\" color=%q opacity=%q sp=\"%.3f\"/>\n"
-	dotfmt  = "<ellipse xp=\"%.7f\" yp=\"%.7f\" hr=\"100\"color=%q opacity=%q wp=\"%.3f\"/>\n"
+	linefmt = "<line xp1=\"%s\" yp1=\"%s\" xp2=\"%s\" yp2=\"%s\" color=\"%s\" opacity=\"%s\" sp=\"%.3f\"/>\n"
+	dotfmt  = "<ellipse xp=\"%s\" yp=\"%s\" hr=\"100\" color=\"%s\" opacity=\"%s\" wp=\"%.3f\"/>\n"
+	rectfmt = "<rect xp=\"%s\" yp=\"%s\" wp=\"%s\" hp=\"%s\" color=\"%s\" opacity=\"%s\"/>\n"
 )
 
 // vmap maps one interval to another
@@ -34,68 +219,241 @@ func vmap(value float64, low1 float64, high1 float64, low2 float64, high2 float6
 	return low2 + (high2-low2)*(value-low1)/(high1-low1)
 }
 
-// colorop makes a color and optional opacity in the form of name:op
-func colorop(color string) (string, string) {
-	ci := strings.Index(color, ":")
-	op := "100"
-	if ci > 0 && ci < len(color) {
-		op = color[ci+1:]
-		color = color[0:ci]
+// mappoint projects a geographic coordinate if there is a Projection, maps it
+// to the screen box, applies any WarpFunc, then with Relative rescales the
+// screen box to 0-100
+func (c Config) mappoint(p shp.Point, g Geometry) (float64, float64) {
+	if c.Projection != nil {
+		p.X, p.Y = c.Projection.Project(p.X, p.Y)
+	}
+	if c.Relative && c.WarpFunc == nil {
+		// map directly, so the corners come out as exactly 0 and 100
+		return scalemap(p.X, g.Longmin, g.Longmax, 0, 100, c.ScaleX), scalemap(p.Y, g.Latmin, g.Latmax, 0, 100, c.ScaleY)
+	}
+	x := scalemap(p.X, g.Longmin, g.Longmax, g.Xmin, g.Xmax, c.ScaleX)
+	y := scalemap(p.Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax, c.ScaleY)
+	if c.WarpFunc != nil {
+		x, y = c.WarpFunc(x, y)
+	}
+	if c.Relative {
+		x = vmap(x, g.Xmin, g.Xmax, 0, 100)
+		y = vmap(y, g.Ymin, g.Ymax, 0, 100)
+	}
+	return x, y
+}
+
+// Scale is how an axis maps values to the screen
+type Scale int
+
+const (
+	// Linear maps values in proportion (the default)
+	Linear Scale = iota
+	// Log maps the logarithms of values; the bounds must be positive,
+	// and values that are not are drawn at the low edge
+	Log
+)
+
+// scalemap is vmap on the given scale. A log scale with bounds that are not
+// positive falls back to linear.
+func scalemap(value, low1, high1, low2, high2 float64, s Scale) float64 {
+	if s != Log || low1 <= 0 || high1 <= 0 {
+		return vmap(value, low1, high1, low2, high2)
+	}
+	if value <= 0 {
+		return low2
+	}
+	return vmap(math.Log(value), math.Log(low1), math.Log(high1), low2, high2)
+}
+
+// UnmapPoint converts a screen coordinate back to longitude and latitude, the
+// inverse of the mapping from g's geographic bounds to its screen box.
+// It does not undo a Config Projection, WarpFunc, Relative, Log scale or CentralMeridian.
+func UnmapPoint(x, y float64, g Geometry) (lon, lat float64) {
+	return vmap(x, g.Xmin, g.Xmax, g.Longmin, g.Longmax), vmap(y, g.Ymin, g.Ymax, g.Latmin, g.Latmax)
+}
+
+// ColorOp splits a color and optional opacity in the form of name:op.
+// The opacity defaults to 100, and is clamped to 0-100; one that is
+// missing or not a number is taken as 100.
+func ColorOp(s string) (color, opacity string) {
+	color = strings.TrimSpace(s)
+	opacity = "100"
+	ci := strings.LastIndex(color, ":")
+	if ci > 0 {
+		op := strings.TrimSpace(color[ci+1:])
+		color = strings.TrimSpace(color[0:ci])
+		if v, err := strconv.ParseFloat(op, 64); err == nil && !math.IsNaN(v) {
+			if v >= 0 && v <= 100 {
+				opacity = op
+			} else {
+				opacity = strconv.FormatFloat(clamp(v, 0, 100), 'f', -1, 64)
+			}
+		}
 	}
-	return color, op
+	return color, opacity
+}
+
+// xmlesc escapes a string for use as an XML attribute value
+var xmlesc = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "'", "&apos;").Replace
+
+// colorattr is ColorOp with both parts escaped for use in markup
+func colorattr(color string) (string, string) {
+	fill, op := ColorOp(color)
+	return xmlesc(fill), xmlesc(op)
 }
 
 // deckpolygon makes deck markup for a polygon given x, y coordinates slices
-func deckpolygon(w io.Writer, x, y []float64, color string) {
+func deckpolygon(w io.Writer, x, y []float64, color string, prec int) {
 	nc := len(x)
 	//fmt.Fprintf(os.Stderr, "xlen=%03d\n\n", nc)
 	if nc < 3 || nc != len(y) {
 		return
 	}
-	fill, op := colorop(color)
+	fill, op := colorattr(color)
 	end := nc - 1
-	fmt.Fprintf(w, "<polygon color=%q opacity=%q xc=\"%.5f", fill, op, x[0])
-	for i := 1; i < nc; i++ {
-		fmt.Fprintf(w, " %.5f", x[i])
+	buf := fmt.Appendf(nil, "<polygon color=\"%s\" opacity=\"%s\" xc=\"", fill, op)
+	buf = appendcoords(buf, x, ' ', prec)
+	buf = appendcoord(append(buf, ' '), x[end], prec)
+	buf = append(buf, "\" yc=\""...)
+	buf = appendcoords(buf, y, ' ', prec)
+	buf = appendcoord(append(buf, ' '), y[end], prec)
+	buf = append(buf, "\"/>\n"...)
+	w.Write(buf)
+}
+
+// appendcoords appends coordinates to prec decimal places, separated by sep.
+// The fixed-point 'f' format never uses an exponent, which deck cannot parse,
+// however large (projected meters) or small the value.
+func appendcoords(buf []byte, vs []float64, sep byte, prec int) []byte {
+	for i, v := range vs {
+		if i > 0 {
+			buf = append(buf, sep)
+		}
+		buf = appendcoord(buf, v, prec)
+	}
+	return buf
+}
+
+// appendcoord appends a coordinate to prec decimal places; at 0 it is a
+// plain integer, with no decimal point and no "-0"
+func appendcoord(buf []byte, v float64, prec int) []byte {
+	if prec == 0 {
+		r := math.Round(v)
+		if r == 0 {
+			r = 0 // not -0
+		}
+		return strconv.AppendFloat(buf, r, 'f', 0, 64)
 	}
-	fmt.Fprintf(w, " %.5f\" ", x[end])
-	fmt.Fprintf(w, "yc=\"%.5f", y[0])
-	for i := 1; i < nc; i++ {
-		fmt.Fprintf(w, " %.5f", y[i])
+	return strconv.AppendFloat(buf, v, 'f', prec, 64)
+}
+
+// withscreen records the width of the screen box of g in output units,
+// 100 with Relative, for Config.CanvasWidth
+func (c Config) withscreen(g Geometry) Config {
+	c.screen = math.Abs(g.Xmax - g.Xmin)
+	if c.Relative {
+		c.screen = 100
 	}
-	fmt.Fprintf(w, " %.5f\"/>\n", y[end])
+	return c
+}
+
+// num formats a coordinate to prec decimal places, as appendcoord
+func num(v float64, prec int) string {
+	return string(appendcoord(nil, v, prec))
+}
+
+// precision returns the decimal places of polygon coordinates
+func (c Config) precision() int {
+	return c.decimals(5)
+}
+
+// decimals returns the decimal places of coordinates, following
+// Config.CanvasWidth or Config.Precision, or def if neither is set
+func (c Config) decimals(def int) int {
+	switch {
+	case c.CanvasWidth > 0 && c.screen > 0:
+		return max(0, int(math.Ceil(math.Log10(c.CanvasWidth/c.screen))))
+	case c.Precision > 0:
+		return c.Precision
+	case c.Precision < 0:
+		return 0
+	}
+	return def
 }
 
 // deckdot makes a series of circles in deck markup from a set of (x,y) coordinates
-func deckdot(w io.Writer, x, y []float64, color string, size float64) {
-	fill, op := colorop(color)
+func deckdot(w io.Writer, x, y []float64, color string, size float64, prec int) {
+	fill, op := colorattr(color)
 	for i := range len(x) {
-		fmt.Fprintf(w, dotfmt, x[i], y[i], fill, op, size)
+		fmt.Fprintf(w, dotfmt, num(x[i], prec), num(y[i], prec), fill, op, size)
 	}
 }
 
-// deckpolyline makes a series of lines in deck markup from a set of (x,y) coordinates
-func deckpolyline(w io.Writer, x, y []float64, color string, size float64) {
-	fill, op := colorop(color)
+// deckpolyline makes a series of lines in deck markup from a set of (x,y) coordinates,
+// joining each point to the next; rings repeat their first point to close
+func deckpolyline(w io.Writer, x, y []float64, color string, size float64, prec int) {
+	fill, op := colorattr(color)
 	lx := len(x)
+	if lx < 2 {
+		return
+	}
 	for i := 0; i < lx-1; i++ {
-		fmt.Fprintf(w, linefmt, x[i], y[i], x[i+1], y[i+1], fill, op, size)
+		fmt.Fprintf(w, linefmt, num(x[i], prec), num(y[i], prec), num(x[i+1], prec), num(y[i+1], prec), fill, op, size)
+	}
+}
+
+// deckglow makes a soft dot from concentric circles of decreasing opacity
+func deckglow(w io.Writer, x, y float64, c Config) {
+	fill, op := ColorOp(c.color)
+	base, err := strconv.ParseFloat(op, 64)
+	if err != nil {
+		base = 100
+	}
+	falloff := c.GlowFalloff
+	if falloff <= 0 {
+		falloff = 2
+	}
+	fill = xmlesc(fill)
+	n := float64(c.GlowRings)
+	for i := 1; i <= c.GlowRings; i++ {
+		f := float64(i) / n // 0 at the outer edge, 1 at the core
+		ringop := strconv.FormatFloat(base*math.Pow(f, falloff), 'f', 1, 64)
+		c.dot(w, x, y, fill, ringop, c.shapesize*(2-f))
 	}
-	fmt.Fprintf(w, linefmt, x[0], y[0], x[lx-1], y[lx-1], fill, op, size)
 }
 
 // mapshape writes markup to the destination according to the specified shape
-func mapshape(w io.Writer, x, y []float64, shape string, color string, size float64) {
+func mapshape(w io.Writer, x, y []float64, shape string, c Config) {
+	if c.Format == SVG {
+		svgshape(w, x, y, shape, c)
+		return
+	}
+	color, size := c.color, c.shapesize
 	switch shape {
 	case "p", "poly", "region", "polygon":
-		deckpolygon(w, x, y, color)
+		deckpolygon(w, x, y, color, c.precision())
 	case "l", "line", "border":
-		deckpolyline(w, x, y, color, size)
+		deckpolyline(w, x, y, color, size, c.decimals(7))
 	case "d", "dot", "circle":
-		deckdot(w, x, y, color, size)
+		if c.GlowRings > 0 {
+			for i := range x {
+				deckglow(w, x[i], y[i], c)
+			}
+			return
+		}
+		deckdot(w, x, y, color, size, c.decimals(7))
 	}
 }
 
+// ispolygon reports whether the map type draws filled polygons
+func ispolygon(shape string) bool {
+	switch shape {
+	case "p", "poly", "region", "polygon":
+		return true
+	}
+	return false
+}
+
 // Open is a wrapper of shp.Open
 func Open(s string) (*shp.Reader, error) {
 	return shp.Open(s)
@@ -106,26 +464,11 @@ func Open(s string) (*shp.Reader, error) {
 // the coordinates are processed in the order specified by a vector that contains
 // the coordinate indicies.
 func PolygonCoords(dest io.Writer, poly *shp.Polygon, g Geometry, c Config) {
-	// for every part...
-	last := poly.NumParts - 1
-	for i := range last {
-		// index into each part, reading coordinates, and map to map geometries
-		x := []float64{}
-		y := []float64{}
-		for j := poly.Parts[i]; j < poly.Parts[i+1]; j++ {
-			x = append(x, vmap(poly.Points[j].X, g.Longmin, g.Longmax, g.Xmin, g.Xmax))
-			y = append(y, vmap(poly.Points[j].Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax))
-		}
-		mapshape(dest, x, y, c.maptype, c.color, c.shapesize)
-	}
-	// process the last part
-	x := []float64{}
-	y := []float64{}
-	for k := poly.Parts[last]; k < poly.NumPoints; k++ {
-		x = append(x, vmap(poly.Points[k].X, g.Longmin, g.Longmax, g.Xmin, g.Xmax))
-		y = append(y, vmap(poly.Points[k].Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax))
+	c = c.withscreen(g)
+	if c.MinFeatureSize > 0 && tinyfeature(dest, poly.Points, g, c) {
+		return
 	}
-	mapshape(dest, x, y, c.maptype, c.color, c.shapesize)
+	mapparts(dest, poly.Points, poly.Parts, poly.NumPoints, g, c, true)
 }
 
 // polygonCoords converts a set of coordinates and makes polylines
@@ -133,45 +476,379 @@ func PolygonCoords(dest io.Writer, poly *shp.Polygon, g Geometry, c Config) {
 // the coordinates are processed in the order specified by a vector that contains
 // the coordinate indicies.
 func PolylineCoords(dest io.Writer, poly *shp.PolyLine, g Geometry, c Config) {
+	mapparts(dest, poly.Points, poly.Parts, poly.NumPoints, g, c, false)
+}
+
+// MappedPolygon returns the screen coordinates of a polygon, mapped from g,
+// as data instead of markup: one element per part (ring), in file order,
+// holding that part's x coordinates and then its y coordinates.
+func MappedPolygon(poly *shp.Polygon, g Geometry) [][2][]float64 {
+	parts := screenparts(partpoints(poly.Points, poly.Parts, poly.NumPoints), g, Config{})
+	out := make([][2][]float64, len(parts))
+	for i, part := range parts {
+		out[i] = xyslices(part)
+	}
+	return out
+}
+
+// tinyfeature draws a dot of MinFeatureSize in place of a feature whose mapped
+// extent is smaller than that, reporting whether it did
+func tinyfeature(dest io.Writer, points []shp.Point, g Geometry, c Config) bool {
+	if len(points) == 0 {
+		return false
+	}
+	x := make([]float64, len(points))
+	y := make([]float64, len(points))
+	for i, p := range points {
+		x[i], y[i] = c.mappoint(p, g)
+	}
+	xmin, xmax, ymin, ymax := bounds(x, y)
+	if max(xmax-xmin, ymax-ymin) >= c.MinFeatureSize {
+		return false
+	}
+	fill, op := colorattr(c.color)
+	c.dot(dest, (xmin+xmax)/2, (ymin+ymax)/2, fill, op, c.MinFeatureSize)
+	c.vertices(1)
+	return true
+}
+
+// normalizeparts repairs a Parts array from a malformed file so that it starts
+// at 0, increases, and stays within the points, logging each correction.
+// A Parts array starting at 1 is taken to be 1-based and shifted down.
+func normalizeparts(parts []int32, numpoints int32, c Config) []int32 {
+	if len(parts) == 0 {
+		return parts
+	}
+	ok := parts[0] == 0
+	for i, p := range parts {
+		if p < 0 || p >= numpoints || (i > 0 && p <= parts[i-1]) {
+			ok = false
+		}
+	}
+	if ok {
+		return parts
+	}
+	out := slices.Clone(parts)
+	if out[0] == 1 {
+		c.fix("parts are 1-based, shifted to 0-based")
+		for i := range out {
+			out[i]--
+		}
+	}
+	out = slices.DeleteFunc(out, func(p int32) bool {
+		if p < 0 || p >= numpoints {
+			if c.Logger != nil {
+				c.fix(fmt.Sprintf("part start %d is outside [0, %d), dropped", p, numpoints))
+			}
+			return true
+		}
+		return false
+	})
+	if !slices.IsSorted(out) {
+		c.fix("parts are not in order, sorted")
+		slices.Sort(out)
+	}
+	out = slices.Compact(out)
+	if numpoints > 0 && (len(out) == 0 || out[0] != 0) {
+		c.fix("first part does not start at 0, added a part at 0")
+		out = slices.Insert(out, 0, 0)
+	}
+	return out
+}
+
+// partrange returns the start and end point index of part i
+func partrange(parts []int32, numpoints int32, i int) (int32, int32) {
+	if i == len(parts)-1 {
+		return parts[i], numpoints
+	}
+	return parts[i], parts[i+1]
+}
+
+// mapparts maps every part of a multi-part shape to the screen and writes its markup.
+// closed parts (polygon rings) and open parts (lines) clip differently.
+func mapparts(dest io.Writer, points []shp.Point, parts []int32, numpoints int32, g Geometry, c Config, closed bool) {
+	c = c.withscreen(g)
+	if c.reporting() {
+		for _, p := range c.Parts {
+			if p < 0 || p >= len(parts) {
+				c.skip(fmt.Sprintf("part index %d is not in [0, %d)", p, len(parts)))
+			}
+		}
+	}
+	numpoints = min(numpoints, int32(len(points)))
+	parts = normalizeparts(parts, numpoints, c)
+	var rings [][]shp.Point
+	var partof []int // the part index of each ring, for DebugParts
 	// for every part...
-	last := poly.NumParts - 1
-	for i := range last {
-		// index into each part, reading coordinates, and map to map geometries
-		x := []float64{}
-		y := []float64{}
-		for j := poly.Parts[i]; j < poly.Parts[i+1]; j++ {
-			x = append(x, vmap(poly.Points[j].X, g.Longmin, g.Longmax, g.Xmin, g.Xmax))
-			y = append(y, vmap(poly.Points[j].Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax))
-		}
-		mapshape(dest, x, y, c.maptype, c.color, c.shapesize)
-	}
-	// process the last part
-	x := []float64{}
-	y := []float64{}
-	for k := poly.Parts[last]; k < poly.NumPoints; k++ {
-		x = append(x, vmap(poly.Points[k].X, g.Longmin, g.Longmax, g.Xmin, g.Xmax))
-		y = append(y, vmap(poly.Points[k].Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax))
+	for i := range parts {
+		if len(c.Parts) > 0 && !slices.Contains(c.Parts, i) {
+			continue
+		}
+		start, end := partrange(parts, numpoints, i)
+		part := points[start:end]
+		greatcircle := !closed && c.GreatCircleSteps > 0
+		if greatcircle {
+			part = densifyGreatCircle(part, c.GreatCircleSteps)
+		}
+		part = Simplify(part, c.Simplify)
+		pieces := c.recenter(part, closed, greatcircle)
+		// ring reports whether the pieces are still closed rings: outlines are
+		// clipped as lines so the clip edges are not drawn, and filled fragments
+		// of a mask are merged back together so its diagonals leave no seams
+		ring := closed
+		if regions := c.clipregions(); len(regions) > 0 {
+			ring = closed && ispolygon(c.maptype)
+			var clipped [][]shp.Point
+			for _, piece := range pieces {
+				var frags [][]shp.Point
+				for _, region := range regions {
+					switch {
+					case ring:
+						if cp := ClipPolygon(piece, region); len(cp) > 0 {
+							frags = append(frags, cp)
+						}
+					case closed && len(piece) > 0 && piece[0] != piece[len(piece)-1]:
+						frags = append(frags, ClipPolyline(append(slices.Clip(piece), piece[0]), region)...)
+					default:
+						frags = append(frags, ClipPolyline(piece, region)...)
+					}
+				}
+				if ring && len(c.ClipMask) > 0 {
+					frags = mergefragments(frags)
+				} else if len(c.ClipMask) > 0 {
+					frags = joinlines(frags)
+				}
+				clipped = append(clipped, frags...)
+			}
+			if len(clipped) == 0 {
+				clipped = [][]shp.Point{nil}
+			}
+			pieces = clipped
+		}
+		for _, pts := range pieces {
+			if len(pts) == 0 {
+				if c.reporting() {
+					c.skip(fmt.Sprintf("part %d is outside the clip polygon", i))
+				}
+				continue
+			}
+			if ring && len(pts) < 3 {
+				if c.reporting() {
+					c.skip(fmt.Sprintf("part %d has too few points (%d)", i, len(pts)))
+				}
+				continue
+			}
+			if !ring && len(pts) < 2 {
+				if c.DegenerateDots {
+					x, y := c.mappoint(pts[0], g)
+					fill, op := colorattr(c.color)
+					c.dot(dest, x, y, fill, op, c.shapesize)
+					c.vertices(1)
+				} else if c.reporting() {
+					c.skip(fmt.Sprintf("part %d has a single point", i))
+				}
+				continue
+			}
+			pts = Smooth(pts, c.Smooth, ring)
+			if ring && pts[0] != pts[len(pts)-1] {
+				pts = append(slices.Clip(pts), pts[0]) // outlines end where they start
+			}
+			rings = append(rings, pts)
+			partof = append(partof, i)
+		}
+	}
+	if c.GroupParts && len(rings) > 1 {
+		if c.Format == SVG && closed && ispolygon(c.maptype) {
+			svgpath(dest, rings, g, c)
+			return
+		}
+		name := fmt.Sprintf("record %d", c.record)
+		c.begingroup(dest, name)
+		defer c.endgroup(dest)
+	}
+	if c.DebugParts {
+		if pal, _ := PaletteNamed("set1"); len(pal) > 0 {
+			for j, pts := range rings {
+				pc := c
+				pc.color = pal[partof[j]%len(pal)]
+				mapring(dest, pts, g, pc, closed && ispolygon(c.maptype))
+			}
+			return
+		}
+	}
+	// filled polygons join each outer ring with its holes, outlines draw every ring
+	if closed && ispolygon(c.maptype) {
+		for _, group := range ringgroups(normalizewinding(rings, c.AssumeWinding)) {
+			mapring(dest, bridgeholes(group), g, c, true)
+		}
+		return
+	}
+	for _, pts := range rings {
+		mapring(dest, pts, g, c, false)
+	}
+}
+
+// mapring maps a set of points to the screen and writes the markup
+func mapring(dest io.Writer, pts []shp.Point, g Geometry, c Config, filled bool) {
+	for _, piece := range mappedpieces(pts, g, c, filled) {
+		x, y := piece[0], piece[1]
+		mapshape(dest, x, y, c.maptype, c)
+		c.vertices(len(x))
+		if filled && c.FillPattern != "" {
+			deckpattern(dest, x, y, c)
+		}
+	}
+}
+
+// mappedpieces maps a set of points to the screen and applies MinSegment,
+// BufferInset to filled rings, and ClipToScreen, giving the pieces to draw
+func mappedpieces(pts []shp.Point, g Geometry, c Config, filled bool) [][2][]float64 {
+	// reading coordinates, and map to map geometries
+	x := make([]float64, len(pts))
+	y := make([]float64, len(pts))
+	for j, p := range pts {
+		x[j], y[j] = c.mappoint(p, g)
+	}
+	if c.MinSegment > 0 {
+		x, y = dropshort(x, y, c.MinSegment, ispolygon(c.maptype))
+	}
+	if filled && c.BufferInset > 0 {
+		if x, y = inset(x, y, c.BufferInset); len(x) == 0 {
+			c.skip("the ring vanishes under BufferInset")
+			return nil
+		}
+	}
+	if !c.ClipToScreen {
+		return [][2][]float64{{x, y}}
 	}
-	mapshape(dest, x, y, c.maptype, c.color, c.shapesize)
+	return c.screenclip(x, y, g)
+}
+
+// dropshort merges away points closer than length to the last point kept,
+// always keeping the first and last points. Polygons keep at least 3 points:
+// one that would collapse further is left as it was.
+func dropshort(x, y []float64, length float64, polygon bool) ([]float64, []float64) {
+	n := len(x)
+	if n < 3 {
+		return x, y
+	}
+	kx, ky := []float64{x[0]}, []float64{y[0]}
+	for i := 1; i < n-1; i++ {
+		if math.Hypot(x[i]-kx[len(kx)-1], y[i]-ky[len(ky)-1]) >= length {
+			kx, ky = append(kx, x[i]), append(ky, y[i])
+		}
+	}
+	// the last point replaces a kept point too close to it
+	if m := len(kx); m > 1 && math.Hypot(x[n-1]-kx[m-1], y[n-1]-ky[m-1]) < length {
+		kx, ky = kx[:m-1], ky[:m-1]
+	}
+	kx, ky = append(kx, x[n-1]), append(ky, y[n-1])
+	if polygon && len(kx) < 3 {
+		return x, y
+	}
+	return kx, ky
 }
 
 // multipointCoords converts a set of coordinates and makes circles for each coordinate.
 // the coordinates are mapped from geographical coordinates to screen bounding box
 func MultipointCoords(dest io.Writer, mp *shp.MultiPoint, g Geometry, c Config) {
+	c = c.withscreen(g)
 	x := []float64{}
 	y := []float64{}
 	for i := int32(0); i < mp.NumPoints; i++ {
-		x = append(x, vmap(mp.Points[i].X, g.Longmin, g.Longmax, g.Xmin, g.Xmax))
-		y = append(y, vmap(mp.Points[i].Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax))
+		p := c.recenterpoint(mp.Points[i])
+		if !c.inclip(p) {
+			continue
+		}
+		px, py := c.mappoint(p, g)
+		if !c.onscreen(px, py, g) {
+			continue
+		}
+		x = append(x, px)
+		y = append(y, py)
 	}
-	mapshape(dest, x, y, "dot", c.color, c.shapesize)
+	if c.Marker != MarkerDot && c.GlowRings == 0 {
+		for i := range x {
+			c.marker(dest, x[i], y[i])
+		}
+	} else {
+		mapshape(dest, x, y, "dot", c)
+	}
+	c.vertices(len(x))
 }
 
 // pointCoords places a circle at a coordinate.
 // the coordinates are mapped from geographical coordinates to screen bounding box.
 func PointCoords(dest io.Writer, p *shp.Point, g Geometry, c Config) {
-	x := vmap(p.X, g.Longmin, g.Longmax, g.Xmin, g.Xmax)
-	y := vmap(p.Y, g.Latmin, g.Latmax, g.Ymin, g.Ymax)
-	fill, op := colorop(c.color)
-	fmt.Fprintf(dest, dotfmt, x, y, fill, op, c.shapesize)
+	c = c.withscreen(g)
+	pt := c.recenterpoint(*p)
+	if !c.inclip(pt) {
+		c.skip("point is outside the clip polygon")
+		return
+	}
+	x, y := c.mappoint(pt, g)
+	if !c.onscreen(x, y, g) {
+		c.skip("point is outside the screen box")
+		return
+	}
+	c.vertices(1)
+	if c.GlowRings > 0 {
+		deckglow(dest, x, y, c)
+		return
+	}
+	c.marker(dest, x, y)
+}
+
+// reporting reports whether skip counts or logs anything, so that callers
+// format a reason only when it is wanted
+func (c Config) reporting() bool {
+	return c.Logger != nil || c.stats != nil
+}
+
+// skip reports a skipped feature to the Logger, if one is set
+func (c Config) skip(reason string) {
+	if c.stats != nil {
+		c.stats.Skipped++
+	}
+	if c.Logger != nil {
+		c.Logger(fmt.Sprintf("record %d: skipped: %s", c.record, reason))
+	}
+}
+
+// fix reports a repair made to malformed input to the Logger, if one is set
+func (c Config) fix(what string) {
+	if c.Logger != nil {
+		c.Logger(fmt.Sprintf("record %d: corrected: %s", c.record, what))
+	}
+}
+
+// vertices counts coordinates written, when collecting stats
+func (c Config) vertices(n int) {
+	if c.stats != nil {
+		c.stats.Vertices += n
+	}
+}
+
+// Shadow is a copy of each feature drawn beneath it, offset in screen units
+type Shadow struct {
+	Dx, Dy float64
+	Color  string // color and opacity, such as "black:30"
+}
+
+// shadow returns the Config that draws the shadow of a feature
+func (c Config) shadow() Config {
+	sc := c
+	sc.color = c.Shadow.Color
+	sc.Shadow = Shadow{}
+	sc.FillPattern = ""
+	sc.Logger = nil
+	sc.stats = nil
+	warp, dx, dy := c.WarpFunc, c.Shadow.Dx, c.Shadow.Dy
+	sc.WarpFunc = func(x, y float64) (float64, float64) {
+		if warp != nil {
+			x, y = warp(x, y)
+		}
+		return x + dx, y + dy
+	}
+	return sc
 }
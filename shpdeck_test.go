@@ -0,0 +1,352 @@
+package shpdeck
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// decimals returns the decimal places of the first number in the xc attribute
+func decimals(tb testing.TB, markup string) int {
+	tb.Helper()
+	m := regexp.MustCompile(`xc="-?\d+(\.\d*)?[ "]`).FindStringSubmatch(markup)
+	if m == nil {
+		tb.Fatalf("no polygon in %q", markup)
+	}
+	return max(0, len(m[1])-1)
+}
+
+func TestCanvasWidthPrecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    float64 // of the screen box
+		canvas   float64
+		relative bool
+		want     int
+	}{
+		{"a pixel per unit", 1000, 1000, false, 0},
+		{"a hundred pixels per unit", 10, 1000, false, 2},
+		{"ten pixels per unit", 100, 1000, false, 1},
+		{"relative", 1000, 1000, true, 1},
+		{"relative on a small box", 10, 1000, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Geometry{Xmin: 0, Xmax: tt.width, Ymin: 0, Ymax: tt.width, Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+			c := NewConfig("polygon", "red", 0)
+			c.CanvasWidth = tt.canvas
+			c.Relative = tt.relative
+			var b strings.Builder
+			PolygonCoords(&b, square(1, 1, 3), g, c)
+			if got := decimals(t, b.String()); got != tt.want {
+				t.Errorf("%d decimal places, want %d: %s", got, tt.want, b.String())
+			}
+		})
+	}
+}
+
+func TestIntegerPrecision(t *testing.T) {
+	// sizes and opacities are not coordinates
+	sizes := regexp.MustCompile(` (sp|wp|stroke-width|r|fill-opacity|stroke-opacity)="[^"]*"`)
+	tests := []struct {
+		name    string
+		maptype string
+		format  Format
+	}{
+		{"deck polygon", "polygon", Deck},
+		{"deck line", "line", Deck},
+		{"deck dot", "dot", Deck},
+		{"svg polygon", "polygon", SVG},
+		{"svg line", "line", SVG},
+		{"svg dot", "dot", SVG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig(tt.maptype, "red", 0.2)
+			c.Format = tt.format
+			c.Precision = IntegerPrecision
+			var b strings.Builder
+			// a corner just west of the screen box rounds to 0, not -0
+			PolygonCoords(&b, square(-0.01, 1.234, 3.333), unit, c)
+			out := sizes.ReplaceAllString(b.String(), "")
+			if out == "" {
+				t.Fatal("nothing drawn")
+			}
+			if strings.Contains(out, ".") || strings.Contains(out, "-0") {
+				t.Errorf("coordinates are not whole numbers:\n%s", b.String())
+			}
+		})
+	}
+}
+
+func TestDefaultPrecision(t *testing.T) {
+	tests := []struct {
+		maptype, want string
+	}{
+		{"polygon", `xc="10.00000 `},
+		{"line", `xp1="10.0000000"`},
+		{"dot", `xp="10.0000000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.maptype, func(t *testing.T) {
+			var b strings.Builder
+			PolygonCoords(&b, square(1, 1, 3), unit, NewConfig(tt.maptype, "red", 0.2))
+			if !strings.Contains(b.String(), tt.want) {
+				t.Errorf("no %s in\n%s", tt.want, b.String())
+			}
+		})
+	}
+}
+
+// TestMarkupEscaping checks that names from data come back unchanged from
+// the attribute values and text they are written into
+func TestMarkupEscaping(t *testing.T) {
+	for _, name := range []string{"A & B County", "Côte d'Ivoire", `the "Big" <Apple>`, "Zürich"} {
+		t.Run(name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			var b strings.Builder
+			BeginGroup(&b, name)
+			c.text(&b, DefaultTextStyle, 10, 10, name, 0)
+			EndGroup(&b)
+			d := xml.NewDecoder(strings.NewReader(b.String()))
+			var group, text string
+			for {
+				tok, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%v in\n%s", err, b.String())
+				}
+				switch tok := tok.(type) {
+				case xml.StartElement:
+					if tok.Name.Local == "group" {
+						group = tok.Attr[0].Value
+					}
+				case xml.CharData:
+					text += strings.TrimSpace(string(tok))
+				}
+			}
+			if group != name || text != name {
+				t.Errorf("group %q and text %q, want %q", group, text, name)
+			}
+		})
+	}
+}
+
+func TestNormalizeParts(t *testing.T) {
+	tests := []struct {
+		name      string
+		parts     []int32
+		numpoints int32
+		want      []int32
+		fixes     int
+	}{
+		{"valid", []int32{0, 5, 10}, 15, []int32{0, 5, 10}, 0},
+		{"non-monotonic", []int32{0, 10, 5}, 15, []int32{0, 5, 10}, 1},
+		{"repeated", []int32{0, 5, 5}, 15, []int32{0, 5}, 0},
+		{"one-based", []int32{1, 6, 11}, 15, []int32{0, 5, 10}, 1},
+		{"out of range", []int32{0, 5, 99, -3}, 15, []int32{0, 5}, 2},
+		{"first part missing", []int32{4, 8}, 15, []int32{0, 4, 8}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			c := NewConfig("polygon", "red", 0)
+			c.Logger = func(s string) { logged = append(logged, s) }
+			got := normalizeparts(tt.parts, tt.numpoints, c)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parts %v, want %v", got, tt.want)
+			}
+			if len(logged) != tt.fixes {
+				t.Errorf("%d corrections logged, want %d: %q", len(logged), tt.fixes, logged)
+			}
+		})
+	}
+}
+
+// TestMalformedPartsRender draws a record with a non-monotonic Parts array
+// without panicking, every ring in one piece
+func TestMalformedPartsRender(t *testing.T) {
+	poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, outerB, holeB}))
+	poly.Parts = []int32{10, 0, 5}
+	var b strings.Builder
+	PolygonCoords(&b, &poly, unit, NewConfig("line", "red", 0.2))
+	if n := len(lineelements(t, b.String())); n != 12 {
+		t.Errorf("%d lines, want the 12 edges of 3 squares", n)
+	}
+}
+
+// TestMalformedPartsHelpers gives line labels and centerlines records with
+// parts out of order and past the points, which they repair as the renderer does
+func TestMalformedPartsHelpers(t *testing.T) {
+	for _, parts := range [][]int32{{10, 0, 5}, {0, 99}, {1, 6, 11}} {
+		poly := shp.Polygon(*shp.NewPolyLine([][]shp.Point{outerA, outerB, holeB}))
+		poly.Parts = parts
+		line := shp.PolyLine(poly)
+		var b strings.Builder
+		RenderLineLabel(&b, &line, unit, "label", NewConfig("line", "black", 0))
+		if !strings.Contains(b.String(), "<text ") {
+			t.Errorf("parts %v: no line label", parts)
+		}
+		if len(Centerline(&poly)) == 0 {
+			t.Errorf("parts %v: no centerline", parts)
+		}
+	}
+}
+
+// TestDegenerateParts draws a polyline with a single-point part: the part
+// draws nothing, or one dot with DegenerateDots, and no part is closed
+func TestDegenerateParts(t *testing.T) {
+	line := shp.NewPolyLine([][]shp.Point{
+		{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 5, Y: 5}},
+		{{X: 8, Y: 8}},
+		{{X: 2, Y: 9}, {X: 4, Y: 9}},
+	})
+	for _, dots := range []bool{false, true} {
+		c := NewConfig("line", "red", 0.2)
+		c.DegenerateDots = dots
+		var b strings.Builder
+		PolylineCoords(&b, line, unit, c)
+		want := [][4]float64{{10, 10, 50, 10}, {50, 10, 50, 50}, {20, 90, 40, 90}}
+		if got := lineelements(t, b.String()); !slices.Equal(got, want) {
+			t.Errorf("DegenerateDots %v: lines %v, want %v", dots, got, want)
+		}
+		ndots := 0
+		if dots {
+			ndots = 1
+		}
+		if n := strings.Count(b.String(), "<ellipse "); n != ndots {
+			t.Errorf("DegenerateDots %v: %d dots, want %d", dots, n, ndots)
+		}
+		if dots && !strings.Contains(b.String(), `<ellipse xp="80.0000000" yp="80.0000000"`) {
+			t.Errorf("DegenerateDots: no dot at the single point:\n%s", b.String())
+		}
+	}
+}
+
+func TestRelativeCorners(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Geometry
+	}{
+		{"unit", unit},
+		{"odd bounds", Geometry{Xmin: 13.7, Xmax: 871.3, Ymin: 3.1, Ymax: 611.9, Longmin: -124.7331, Longmax: -66.9498, Latmin: 24.5210, Latmax: 49.3845}},
+		{"flipped screen", Geometry{Xmin: 0, Xmax: 960, Ymin: 540, Ymax: 0, Longmin: -0.1, Longmax: 0.3, Latmin: 51.3, Latmax: 51.7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.Relative = true
+			corners := []struct {
+				lon, lat float64
+				x, y     float64
+			}{
+				{tt.g.Longmin, tt.g.Latmin, 0, 0},
+				{tt.g.Longmax, tt.g.Latmax, 100, 100},
+				{tt.g.Longmin, tt.g.Latmax, 0, 100},
+			}
+			for _, k := range corners {
+				if x, y := c.mappoint(shp.Point{X: k.lon, Y: k.lat}, tt.g); x != k.x || y != k.y {
+					t.Errorf("corner (%v, %v) maps to (%v, %v), want (%v, %v)", k.lon, k.lat, x, y, k.x, k.y)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmapPointRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Geometry
+	}{
+		{"unit", unit},
+		{"world", Geometry{Xmin: 5, Xmax: 95, Ymin: 10, Ymax: 90, Longmin: -180, Longmax: 180, Latmin: -90, Latmax: 90}},
+		{"svg, y down", Geometry{Xmin: 0, Xmax: 960, Ymin: 540, Ymax: 0, Longmin: -124.7, Longmax: -66.9, Latmin: 24.5, Latmax: 49.4}},
+	}
+	points := []shp.Point{{X: 0, Y: 0}, {X: 1.5, Y: 2.25}, {X: -100, Y: 40}, {X: 9.999, Y: -0.001}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			for _, p := range points {
+				x, y := c.mappoint(p, tt.g)
+				lon, lat := UnmapPoint(x, y, tt.g)
+				if math.Abs(lon-p.X) > 1e-9 || math.Abs(lat-p.Y) > 1e-9 {
+					t.Errorf("%v maps to (%v, %v) and back to (%v, %v)", p, x, y, lon, lat)
+				}
+			}
+		})
+	}
+}
+
+func TestAppendCoord(t *testing.T) {
+	tests := []struct {
+		v    float64
+		prec int
+		want string
+	}{
+		{4_500_000.25, 5, "4500000.25000"},
+		{-12_345_678.9, 2, "-12345678.90"},
+		{1e21, 0, "1000000000000000000000"},
+		{0.0000001, 7, "0.0000001"},
+		{0.00000001, 5, "0.00000"},
+		{-0.4, 0, "0"},
+		{2.5, 0, "3"},
+	}
+	for _, tt := range tests {
+		if got := string(appendcoord(nil, tt.v, tt.prec)); got != tt.want {
+			t.Errorf("appendcoord(%v, %d) = %q, want %q", tt.v, tt.prec, got, tt.want)
+		}
+	}
+}
+
+// TestProjectedMeters draws data onto a screen box in the millions, as when
+// writing projected meters, and checks no number uses an exponent
+func TestProjectedMeters(t *testing.T) {
+	g := Geometry{Xmin: 2_000_000, Xmax: 9_000_000, Ymin: 1_000_000, Ymax: 6_000_000, Longmin: 0, Longmax: 10, Latmin: 0, Latmax: 10}
+	exponent := regexp.MustCompile(`\d[eE][-+]?\d`)
+	for _, format := range []Format{Deck, SVG} {
+		for _, maptype := range []string{"polygon", "line", "dot"} {
+			c := NewConfig(maptype, "red", 0.2)
+			c.Format = format
+			var b strings.Builder
+			PolygonCoords(&b, square(1, 1, 3), g, c)
+			if b.Len() == 0 || exponent.MatchString(b.String()) {
+				t.Errorf("%v %s:\n%s", format, maptype, b.String())
+			}
+			if !strings.Contains(b.String(), "2700000") {
+				t.Errorf("%v %s: no x of 2700000 in\n%s", format, maptype, b.String())
+			}
+		}
+	}
+}
+
+// TestDropShort merges points closer than the minimum segment to the last
+// point kept, keeping the ends, and leaves polygons at least a triangle
+func TestDropShort(t *testing.T) {
+	tests := []struct {
+		name    string
+		x, y    []float64
+		polygon bool
+		wantx   []float64
+	}{
+		{"jitter", []float64{0, 0.2, 0.4, 1, 1.1, 2}, []float64{0, 0, 0, 0, 0, 0}, false, []float64{0, 1, 2}},
+		{"last point close to a kept one", []float64{0, 1, 1.3}, []float64{0, 0, 0}, false, []float64{0, 1.3}},
+		{"nothing short", []float64{0, 1, 2, 3}, []float64{0, 0, 0, 0}, false, []float64{0, 1, 2, 3}},
+		{"ring", []float64{0, 0, 0.1, 4, 4, 0}, []float64{0, 4, 4, 4, 0, 0}, true, []float64{0, 0, 4, 4, 0}},
+		{"collapsing ring", []float64{0, 0.1, 0.1, 0}, []float64{0, 0, 0.1, 0}, true, []float64{0, 0.1, 0.1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if x, y := dropshort(tt.x, tt.y, 0.5, tt.polygon); !slices.Equal(x, tt.wantx) || len(y) != len(x) {
+				t.Errorf("x %v, want %v", x, tt.wantx)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package shpdeck
+
+import (
+	"math"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Simplify reduces a line or ring with the Douglas-Peucker algorithm, keeping
+// the points that deviate more than tolerance from the simplified shape.
+// The first and last points are always kept.
+func Simplify(pts []shp.Point, tolerance float64) []shp.Point {
+	if tolerance <= 0 || len(pts) < 3 {
+		return pts
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	stack := [][2]int{{0, len(pts) - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		far, index := 0.0, -1
+		for i := span[0] + 1; i < span[1]; i++ {
+			if d := segdistance(pts[i], pts[span[0]], pts[span[1]]); d > far {
+				far, index = d, i
+			}
+		}
+		if index >= 0 && far > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{span[0], index}, [2]int{index, span[1]})
+		}
+	}
+	out := make([]shp.Point, 0, len(pts))
+	for i, p := range pts {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// segdistance is the distance from p to the segment a-b
+func segdistance(p, a, b shp.Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = clamp(((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l, 0, 1)
+	}
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
+
+// ToleranceForZoom is a Simplify tolerance in degrees for a web map zoom
+// level: the width of one pixel at the equator on a 256 pixel tile, that is
+// 360 / (256 * 2^zoom), so zoom 0 is about 1.4 degrees and each level halves it.
+func ToleranceForZoom(zoom int) float64 {
+	return 360 / (256 * math.Pow(2, float64(zoom)))
+}
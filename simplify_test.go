@@ -0,0 +1,50 @@
+package shpdeck
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestSimplify keeps the ends and the points farther than tolerance from
+// the simplified line
+func TestSimplify(t *testing.T) {
+	line := []shp.Point{{X: 0, Y: 0}, {X: 1, Y: 0.1}, {X: 2, Y: -0.1}, {X: 3, Y: 5}, {X: 4, Y: 6}, {X: 5, Y: 7}, {X: 6, Y: 8.05}, {X: 7, Y: 9}}
+	ring := []shp.Point{{X: 0, Y: 0}, {X: 0, Y: 2}, {X: 0.05, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 0}, {X: 0, Y: 0}}
+	tests := []struct {
+		name      string
+		pts       []shp.Point
+		tolerance float64
+		want      []int // indices of the points kept
+	}{
+		{"no tolerance", line, 0, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"small wiggles", line, 0.5, []int{0, 2, 3, 7}},
+		{"tighter", line, 0.01, []int{0, 1, 2, 3, 5, 6, 7}},
+		{"loose", line, 10, []int{0, 7}},
+		{"ring", ring, 0.1, []int{0, 2, 3, 4, 5}},
+		{"two points", line[:2], 1, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []shp.Point
+			for _, i := range tt.want {
+				want = append(want, tt.pts[i])
+			}
+			if got := Simplify(tt.pts, tt.tolerance); !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestToleranceForZoom(t *testing.T) {
+	if got := ToleranceForZoom(0); got != 360.0/256 {
+		t.Errorf("zoom 0 tolerance %g, want %g", got, 360.0/256)
+	}
+	for zoom := range 20 {
+		if got, next := ToleranceForZoom(zoom), ToleranceForZoom(zoom+1); next != got/2 {
+			t.Errorf("zoom %d tolerance %g is not half of %g", zoom+1, next, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package shpdeck
+
+import "github.com/jonas-p/go-shp"
+
+// Smooth passes a Catmull-Rom spline through the points, adding steps-1
+// interpolated points between each pair, so the outline curves through every
+// original vertex. Closed rings wrap around; a repeated closing point is kept.
+func Smooth(pts []shp.Point, steps int, closed bool) []shp.Point {
+	n := len(pts)
+	if steps < 2 || n < 3 {
+		return pts
+	}
+	ring := pts
+	repeat := closed && pts[0] == pts[n-1]
+	if repeat {
+		ring = pts[:n-1]
+		n--
+	}
+	at := func(i int) shp.Point {
+		if closed {
+			return ring[(i+n)%n]
+		}
+		return ring[min(max(i, 0), n-1)]
+	}
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	out := make([]shp.Point, 0, segments*steps+1)
+	for i := range segments {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		for s := range steps {
+			t := float64(s) / float64(steps)
+			t2, t3 := t*t, t*t*t
+			out = append(out, shp.Point{
+				X: 0.5 * (2*p1.X + (p2.X-p0.X)*t + (2*p0.X-5*p1.X+4*p2.X-p3.X)*t2 + (3*p1.X-p0.X-3*p2.X+p3.X)*t3),
+				Y: 0.5 * (2*p1.Y + (p2.Y-p0.Y)*t + (2*p0.Y-5*p1.Y+4*p2.Y-p3.Y)*t2 + (3*p1.Y-p0.Y-3*p2.Y+p3.Y)*t3),
+			})
+		}
+	}
+	if !closed || repeat {
+		out = append(out, at(segments))
+	}
+	return out
+}
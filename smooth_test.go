@@ -0,0 +1,55 @@
+package shpdeck
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestSmooth curves through every original vertex, steps points apart,
+// wrapping closed rings and keeping a repeated closing point
+func TestSmooth(t *testing.T) {
+	line := []shp.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 1}}
+	square := []shp.Point{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 0}}
+	tests := []struct {
+		name   string
+		pts    []shp.Point
+		closed bool
+		want   int
+	}{
+		{"line", line, false, 3*4 + 1},
+		{"ring", square, true, 4 * 4},
+		{"ring with its closing point", append(square, square[0]), true, 4*4 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Smooth(tt.pts, 4, tt.closed)
+			if len(got) != tt.want {
+				t.Fatalf("%d points, want %d", len(got), tt.want)
+			}
+			for i, p := range tt.pts {
+				if i*4 < len(got) && got[i*4] != p {
+					t.Errorf("point %d is %v, want vertex %v", i*4, got[i*4], p)
+				}
+			}
+		})
+	}
+	// the first segment, with straight neighbors, stays straight
+	for _, p := range Smooth(line, 4, false)[:5] {
+		if math.Abs(p.Y) > 1e-9 {
+			t.Errorf("%v is off the straight start of the line", p)
+		}
+	}
+	// the square curves outward, symmetric about its center
+	ring := Smooth(square, 4, true)
+	for i, p := range ring {
+		q := ring[(i+8)%len(ring)]
+		if math.Abs(p.X+q.X-1) > 1e-9 || math.Abs(p.Y+q.Y-1) > 1e-9 {
+			t.Errorf("%v and %v are not opposite", p, q)
+		}
+	}
+	if got := Smooth(line, 1, false); len(got) != len(line) {
+		t.Errorf("one step gives %d points, want the line", len(got))
+	}
+}
@@ -0,0 +1,68 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// RenderAttributeTable writes the named DBF fields of each record as a table
+// of text, a header row of field names at (x, y) and then one row per record
+// not deleted, step apart downward. Config.TableRows limits the rows, noting how many were
+// left out, and Config.TableWidth truncates long values with an ellipsis.
+func RenderAttributeTable(dest io.Writer, r *shp.Reader, fields []string, x, y, step float64, c Config) error {
+	index := make([]int, len(fields))
+	for i, f := range fields {
+		if index[i] = fieldIndex(r, f); index[i] < 0 {
+			return fmt.Errorf("table: no field named %q", f)
+		}
+	}
+	deleted := readerdeleted(r)
+	var live []int // the rows not deleted
+	for row := range r.AttributeCount() {
+		if !isdeleted(deleted, row) {
+			live = append(live, row)
+		}
+	}
+	rows := len(live)
+	shown := rows
+	if c.TableRows > 0 {
+		shown = min(rows, c.TableRows)
+	}
+	cells := make([][]string, 0, shown+1)
+	cells = append(cells, fields)
+	for _, row := range live[:shown] {
+		line := make([]string, len(fields))
+		for i, fi := range index {
+			line[i] = truncate(strings.TrimSpace(c.attr(r, row, fi)), c.TableWidth)
+		}
+		cells = append(cells, line)
+	}
+	ts := c.textstyle(TextStyle{})
+	ts.Align = "left" // columns line up on their left edges
+	// each column is as wide as its widest cell, and a space
+	colx := make([]float64, len(fields))
+	cx := x
+	for i := range fields {
+		colx[i] = cx
+		w := 0.0
+		for _, line := range cells {
+			w = max(w, textwidth(line[i], ts.Size))
+		}
+		cx += w + ts.Size
+	}
+	for j, line := range cells {
+		for i, cell := range line {
+			if cell == "" {
+				continue
+			}
+			c.text(dest, ts, colx[i], y-float64(j)*step, cell, 0)
+		}
+	}
+	if shown < rows {
+		c.text(dest, ts, x, y-float64(len(cells))*step, fmt.Sprintf("… %d more", rows-shown), 0)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+)
+
+const labelsize = 1.5 // default label text size
+
+// TextStyle is the typography of labels, legends and other text.
+// Zero fields take the default of the function writing the text.
+type TextStyle struct {
+	Font  string  // deck font name, such as "sans", "serif" or "mono"
+	Size  float64 // text size
+	Color string  // color, with optional opacity as "name:op"
+	Align string  // "left", "center" or "right"
+}
+
+// DefaultTextStyle is used for fields left empty in Config.Text and in the
+// defaults of each text-writing function
+var DefaultTextStyle = TextStyle{Font: "sans", Size: labelsize, Color: "black", Align: "left"}
+
+// over fills the empty fields of ts from base
+func (ts TextStyle) over(base TextStyle) TextStyle {
+	if ts.Font == "" {
+		ts.Font = base.Font
+	}
+	if ts.Size <= 0 {
+		ts.Size = base.Size
+	}
+	if ts.Color == "" {
+		ts.Color = base.Color
+	}
+	if ts.Align == "" {
+		ts.Align = base.Align
+	}
+	return ts
+}
+
+// textstyle resolves the text style for a call: Config.Text, then the
+// function's defaults, then DefaultTextStyle
+func (c Config) textstyle(defaults TextStyle) TextStyle {
+	return c.Text.over(defaults.over(DefaultTextStyle))
+}
+
+// text writes a text element in the configured format, rotated
+// counter-clockwise by rotation degrees
+func (c Config) text(w io.Writer, ts TextStyle, x, y float64, s string, rotation float64) {
+	fill, op := colorattr(ts.Color)
+	p := c.decimals(5)
+	if c.Format == SVG {
+		fmt.Fprintf(w, "<text x=\"%s\" y=\"%s\" font-size=\"%.3f\" fill=\"%s\" fill-opacity=\"%s\" font-family=\"%s\" text-anchor=\"%s\"",
+			num(x, p), num(y, p), ts.Size, fill, svgop(op), xmlesc(svgfont(ts.Font)), svganchor(ts.Align))
+		if rotation != 0 {
+			fmt.Fprintf(w, " transform=\"rotate(%.2f %s %s)\"", -rotation, num(x, p), num(y, p))
+		}
+		fmt.Fprintf(w, ">%s</text>\n", xmlesc(s))
+		return
+	}
+	fmt.Fprintf(w, "<text xp=\"%s\" yp=\"%s\" sp=\"%.3f\" color=\"%s\" opacity=\"%s\" font=\"%s\" align=\"%s\"",
+		num(x, p), num(y, p), ts.Size, fill, op, xmlesc(ts.Font), xmlesc(ts.Align))
+	if rotation != 0 {
+		fmt.Fprintf(w, " rotation=\"%.2f\"", rotation)
+	}
+	fmt.Fprintf(w, ">%s</text>\n", xmlesc(s))
+}
+
+// svgfont maps the deck font names to generic CSS families;
+// other names are passed through
+func svgfont(font string) string {
+	switch font {
+	case "sans":
+		return "sans-serif"
+	case "mono":
+		return "monospace"
+	}
+	return font
+}
+
+// svganchor maps a deck alignment to an SVG text-anchor
+func svganchor(align string) string {
+	switch align {
+	case "center":
+		return "middle"
+	case "right":
+		return "end"
+	}
+	return "start"
+}
@@ -0,0 +1,44 @@
+package shpdeck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	ts := TextStyle{Font: "sans", Size: 2, Color: "red:50", Align: "center"}
+	tests := []struct {
+		name     string
+		format   Format
+		rotation float64
+		want     []string
+		not      []string
+	}{
+		{"deck", Deck, 0,
+			[]string{`<text xp="10.00000" yp="20.00000" sp="2.000" color="red" opacity="50" font="sans" align="center">a &amp; b</text>`},
+			[]string{"rotation="}},
+		{"deck rotated", Deck, 90, []string{` rotation="90.00"`}, nil},
+		{"svg", SVG, 0,
+			[]string{`<text x="10.00000" y="20.00000" font-size="2.000" fill="red" fill-opacity="0.5" font-family="sans-serif" text-anchor="middle">a &amp; b</text>`},
+			[]string{"xp=", "sp=", "transform="}},
+		{"svg rotated", SVG, 90, []string{` transform="rotate(-90.00 10.00000 20.00000)"`}, []string{"rotation="}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig("polygon", "red", 0)
+			c.Format = tt.format
+			var b strings.Builder
+			c.text(&b, ts, 10, 20, "a & b", tt.rotation)
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("no %s in\n%s", want, b.String())
+				}
+			}
+			for _, not := range tt.not {
+				if strings.Contains(b.String(), not) {
+					t.Errorf("%s in\n%s", not, b.String())
+				}
+			}
+		})
+	}
+}
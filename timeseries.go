@@ -0,0 +1,80 @@
+package shpdeck
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TimeMode selects which features each frame of RenderTimeSeries draws
+type TimeMode int
+
+const (
+	// Cumulative draws the features of the frame's time and all before it
+	Cumulative TimeMode = iota
+	// PerStep draws only the features of the frame's time
+	PerStep
+)
+
+// RenderTimeSeries groups the features by the value of timeField and writes
+// one deck slide per distinct time, in order, returning how many slides it
+// wrote. Times sort numerically when every one is a number, and as text
+// otherwise, which orders ISO 8601 dates and timestamps ("2024-03-01").
+// Features with a blank time are left out.
+func RenderTimeSeries(dest io.Writer, r *shp.Reader, g Geometry, timeField string, mode TimeMode, c Config) (int, error) {
+	field := fieldIndex(r, timeField)
+	if field < 0 {
+		return 0, fmt.Errorf("time series: no field named %q", timeField)
+	}
+	c.deleted = readerdeleted(r)
+	frames := map[string][]record{}
+	for r.Next() {
+		n, s := r.Shape()
+		t := strings.Trim(r.ReadAttribute(n, field), " \x00")
+		if t != "" {
+			frames[t] = append(frames[t], record{row: n, shape: s})
+		}
+	}
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
+	times := sortedtimes(frames)
+	var drawn []record
+	for _, t := range times {
+		if mode == PerStep {
+			drawn = drawn[:0]
+		}
+		drawn = append(drawn, frames[t]...)
+		fmt.Fprintln(dest, "<slide>")
+		err := renderloop(dest, &recordBuffer{recs: drawn, reader: r}, g, c, nil, nil)
+		fmt.Fprintln(dest, "</slide>")
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(times), nil
+}
+
+// sortedtimes orders the times, as numbers if they all are
+func sortedtimes(frames map[string][]record) []string {
+	times := make([]string, 0, len(frames))
+	values := map[string]float64{}
+	numeric := true
+	for t := range frames {
+		times = append(times, t)
+		v, err := strconv.ParseFloat(t, 64)
+		numeric = numeric && err == nil
+		values[t] = v
+	}
+	if numeric {
+		slices.SortFunc(times, func(a, b string) int { return cmp.Compare(values[a], values[b]) })
+	} else {
+		slices.Sort(times)
+	}
+	return times
+}
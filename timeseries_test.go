@@ -0,0 +1,49 @@
+package shpdeck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// TestRenderTimeSeries writes a slide per distinct time, in numeric or text
+// order, drawing the features up to or at that time, and leaves out blanks
+func TestRenderTimeSeries(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []string
+		mode  TimeMode
+		want  [][]float64
+	}{
+		{"numeric cumulative", []string{"10", "9", "10", "", "2"}, Cumulative, [][]float64{{80}, {80, 20}, {80, 20, 0, 40}}},
+		{"numeric per step", []string{"10", "9", "10", "", "2"}, PerStep, [][]float64{{80}, {20}, {0, 40}}},
+		{"dates", []string{"2024-03-01", "2023-12-31", "2024-03-01", "2024-01-15", ""}, PerStep, [][]float64{{20}, {60}, {0, 40}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := make([]shp.Shape, len(tt.times))
+			rows := make([][]any, len(tt.times))
+			for i, v := range tt.times {
+				shapes[i] = square(float64(2*i), 0, 1)
+				rows[i] = []any{v}
+			}
+			r := openShapefile(t, writeShapefile(t, shp.POLYGON, shapes, []shp.Field{shp.StringField("T", 10)}, rows))
+			var b strings.Builder
+			n, err := RenderTimeSeries(&b, r, unit, "T", tt.mode, NewConfig("polygon", "red", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			slides := strings.Split(b.String(), "<slide>")[1:]
+			if n != len(tt.want) || len(slides) != n {
+				t.Fatalf("%d slides, %d written, want %d", n, len(slides), len(tt.want))
+			}
+			for i, slide := range slides {
+				if got := polygonx(t, slide); !slices.Equal(got, tt.want[i]) {
+					t.Errorf("slide %d polygons at x %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
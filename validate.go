@@ -0,0 +1,52 @@
+package shpdeck
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// Validate checks that rendering the shapefile with g and c would produce
+// sensible output, without writing any markup. It reports every problem found:
+// an unknown map type, degenerate screen or geographic bounds, a shape type
+// with no registered renderer, or no feature within the geographic bounds.
+// The map type is the one given to NewConfig or RenderOptions.
+// Validate reads records from r, so open the shapefile again to render it.
+func Validate(r *shp.Reader, g Geometry, c Config) error {
+	var errs []error
+	switch c.maptype {
+	case "p", "poly", "region", "polygon", "l", "line", "border", "d", "dot", "circle":
+	default:
+		errs = append(errs, fmt.Errorf("unknown map type %q (set it with NewConfig or RenderOptions.MapType)", c.maptype))
+	}
+	if g.Xmin == g.Xmax || g.Ymin == g.Ymax {
+		errs = append(errs, fmt.Errorf("empty screen box x %v..%v, y %v..%v", g.Xmin, g.Xmax, g.Ymin, g.Ymax))
+	}
+	if g.Longmin == g.Longmax || g.Latmin == g.Latmax {
+		errs = append(errs, fmt.Errorf("empty geographic bounds longitude %v..%v, latitude %v..%v", g.Longmin, g.Longmax, g.Latmin, g.Latmax))
+	}
+	if _, ok := renderer(r.GeometryType); !ok {
+		errs = append(errs, fmt.Errorf("unsupported shape type %d (see RegisterRenderer)", r.GeometryType))
+	}
+	if !g.Intersects(boxgeo(r.BBox())) {
+		errs = append(errs, errors.New("shapefile extent is outside the geographic bounds"))
+	} else {
+		found := false
+		for !found && r.Next() {
+			_, s := r.Shape()
+			found = s != nil && g.Intersects(boxgeo(s.BBox()))
+		}
+		if err := r.Err(); err != nil {
+			errs = append(errs, err)
+		} else if !found {
+			errs = append(errs, errors.New("no feature is within the geographic bounds"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the options as Validate checks a Geometry and Config
+func (o RenderOptions) Validate(r *shp.Reader) error {
+	return Validate(r, o.Geometry, o.config())
+}
@@ -0,0 +1,67 @@
+package shpdeck
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jonas-p/go-shp"
+)
+
+func TestValidate(t *testing.T) {
+	polygons := func(tb testing.TB) *shp.Reader {
+		return openShapefile(tb, writeShapefile(tb, shp.POLYGON, []shp.Shape{square(1, 1, 2)}, nil, nil))
+	}
+	points := func(tb testing.TB) *shp.Reader {
+		return openShapefile(tb, writeShapefile(tb, shp.POINTZ, []shp.Shape{&shp.PointZ{X: 1, Y: 1, Z: 5}}, nil, nil))
+	}
+	away := Geometry{Xmin: 0, Xmax: 100, Ymin: 0, Ymax: 100, Longmin: 50, Longmax: 60, Latmin: 50, Latmax: 60}
+	empty := unit
+	empty.Xmax = empty.Xmin
+	tests := []struct {
+		name    string
+		r       func(testing.TB) *shp.Reader
+		g       Geometry
+		maptype string
+		want    []string // substrings of the error; none for no error
+	}{
+		{"valid", polygons, unit, "polygon", nil},
+		{"unknown map type", polygons, unit, "blob", []string{`unknown map type "blob"`}},
+		{"empty screen box", polygons, empty, "polygon", []string{"empty screen box"}},
+		{"outside the bounds", polygons, away, "polygon", []string{"outside the geographic bounds"}},
+		{"unregistered shape type", points, unit, "dot", []string{"unsupported shape type 11"}},
+		{"every problem", points, empty, "blob", []string{"unknown map type", "empty screen box", "unsupported shape type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.r(t), tt.g, NewConfig(tt.maptype, "red", 0))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate: no error, want %q", tt.want)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate: %v, want %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateRegistered accepts a shape type once a renderer is registered for it
+func TestValidateRegistered(t *testing.T) {
+	RegisterRenderer(shp.POINTZ, func(w io.Writer, s shp.Shape, g Geometry, c Config) {
+		p := s.(*shp.PointZ)
+		PointCoords(w, &shp.Point{X: p.X, Y: p.Y}, g, c)
+	})
+	t.Cleanup(func() { RegisterRenderer(shp.POINTZ, nil) })
+	r := openShapefile(t, writeShapefile(t, shp.POINTZ, []shp.Shape{&shp.PointZ{X: 1, Y: 1, Z: 5}}, nil, nil))
+	if err := Validate(r, unit, NewConfig("dot", "red", 1)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
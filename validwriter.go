@@ -0,0 +1,228 @@
+package shpdeck
+
+import (
+	"fmt"
+	"io"
+)
+
+// markup scanner states
+const (
+	mText      = iota
+	mTagOpen   // after <
+	mName      // element name
+	mAttrs     // between attributes
+	mAttrName  // attribute name
+	mEq        // after an attribute name, expecting =
+	mQuote     // after =, expecting "
+	mValue     // inside a quoted value
+	mSelfClose // after /, expecting >
+	mEndName   // closing tag name
+	mEndTag    // after a closing tag name, expecting >
+	mBang      // after <!, expecting --
+	mComment   // inside a comment
+)
+
+// ValidatingWriter is a development aid that checks the deck or SVG markup
+// written through it, and fails the write that makes it malformed: unbalanced
+// quotes, attributes without values or not separated by spaces, "--" within
+// comments, and mismatched or unclosed elements. Markup is passed on to the underlying writer until
+// the first error.
+type ValidatingWriter struct {
+	w      io.Writer
+	state  int
+	off    int64
+	name   []byte
+	open   []string // elements awaiting their closing tag
+	space  bool     // whitespace seen since the last attribute value
+	dashes int      // consecutive dashes, in comments
+	err    error
+}
+
+// NewValidatingWriter returns a ValidatingWriter writing to w
+func NewValidatingWriter(w io.Writer) *ValidatingWriter {
+	return &ValidatingWriter{w: w}
+}
+
+func isnamebyte(b byte) bool {
+	return b == '-' || b == '_' || b == ':' || b == '.' ||
+		'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func isspace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (v *ValidatingWriter) fail(format string, args ...any) {
+	v.err = fmt.Errorf("markup: offset %d: %s", v.off, fmt.Sprintf(format, args...))
+}
+
+// scan advances the markup state by one byte
+func (v *ValidatingWriter) scan(b byte) {
+	switch v.state {
+	case mText:
+		if b == '<' {
+			v.state = mTagOpen
+		}
+	case mTagOpen:
+		switch {
+		case b == '/':
+			v.name = v.name[:0]
+			v.state = mEndName
+		case b == '!':
+			v.dashes = 0
+			v.state = mBang
+		case isnamebyte(b):
+			v.name = append(v.name[:0], b)
+			v.state = mName
+		default:
+			v.fail("unexpected %q after <", b)
+		}
+	case mName:
+		switch {
+		case isnamebyte(b):
+			v.name = append(v.name, b)
+		case isspace(b):
+			v.space = true
+			v.state = mAttrs
+		case b == '/':
+			v.state = mSelfClose
+		case b == '>':
+			v.open = append(v.open, string(v.name))
+			v.state = mText
+		default:
+			v.fail("unexpected %q in element name", b)
+		}
+	case mAttrs:
+		switch {
+		case isspace(b):
+			v.space = true
+		case b == '/':
+			v.state = mSelfClose
+		case b == '>':
+			v.open = append(v.open, string(v.name))
+			v.state = mText
+		case isnamebyte(b):
+			if !v.space {
+				v.fail("no space before attribute in <%s>", v.name)
+				return
+			}
+			v.state = mAttrName
+		default:
+			v.fail("unexpected %q in <%s>", b, v.name)
+		}
+	case mAttrName:
+		switch {
+		case isnamebyte(b):
+		case b == '=':
+			v.state = mQuote
+		default:
+			v.fail("attribute without a value in <%s>", v.name)
+		}
+	case mQuote:
+		if b != '"' {
+			v.fail("unquoted attribute value in <%s>", v.name)
+			return
+		}
+		v.state = mValue
+	case mValue:
+		switch b {
+		case '"':
+			v.space = false
+			v.state = mAttrs
+		case '<':
+			v.fail("unbalanced quote in <%s>", v.name)
+		}
+	case mSelfClose:
+		if b != '>' {
+			v.fail("unexpected %q after / in <%s>", b, v.name)
+			return
+		}
+		v.state = mText
+	case mEndName:
+		switch {
+		case isnamebyte(b):
+			v.name = append(v.name, b)
+		case b == '>' || isspace(b):
+			v.closetag()
+			if b == '>' {
+				v.state = mText
+			} else {
+				v.state = mEndTag
+			}
+		default:
+			v.fail("unexpected %q in closing tag", b)
+		}
+	case mEndTag:
+		switch {
+		case b == '>':
+			v.state = mText
+		case !isspace(b):
+			v.fail("unexpected %q in closing tag", b)
+		}
+	case mBang:
+		if b != '-' {
+			v.fail("malformed comment")
+			return
+		}
+		if v.dashes++; v.dashes == 2 {
+			v.dashes = 0
+			v.state = mComment
+		}
+	case mComment:
+		switch {
+		case b == '-':
+			v.dashes++
+		case v.dashes == 2 && b == '>':
+			v.dashes = 0
+			v.state = mText
+		case v.dashes >= 2:
+			v.fail("-- inside a comment")
+		default:
+			v.dashes = 0
+		}
+	}
+}
+
+// closetag matches a closing tag against the innermost open element
+func (v *ValidatingWriter) closetag() {
+	n := len(v.open)
+	if n == 0 {
+		v.fail("</%s> closes nothing", v.name)
+		return
+	}
+	if v.open[n-1] != string(v.name) {
+		v.fail("</%s> closes <%s>", v.name, v.open[n-1])
+		return
+	}
+	v.open = v.open[:n-1]
+}
+
+// Write checks p, then writes it to the underlying writer.
+// After an error, every write fails with it.
+func (v *ValidatingWriter) Write(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	for _, b := range p {
+		if v.scan(b); v.err != nil {
+			return 0, v.err
+		}
+		v.off++
+	}
+	return v.w.Write(p)
+}
+
+// Close reports an error if the markup ends inside a tag, value or comment,
+// or with elements still open. It does not close the underlying writer.
+func (v *ValidatingWriter) Close() error {
+	if v.err != nil {
+		return v.err
+	}
+	switch {
+	case v.state != mText:
+		v.fail("markup ends inside a tag")
+	case len(v.open) > 0:
+		v.fail("<%s> is not closed", v.open[len(v.open)-1])
+	}
+	return v.err
+}
@@ -0,0 +1,34 @@
+package shpdeck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatingWriter(t *testing.T) {
+	tests := []struct {
+		markup string
+		ok     bool
+	}{
+		{`<deck><slide><line xp1="1" yp1="2"/></slide></deck>`, true},
+		{"<!-- record 1 name=\"a- -b\" -->\n<polygon xc=\"1\"/>", true},
+		{`<line xp1="1"yp1="2"/>`, false},
+		{`<line xp1=1/>`, false},
+		{`<line xp1/>`, false},
+		{`<line xp1="1/>`, false},
+		{`<slide></deck>`, false},
+		{`<slide>`, false},
+		{`<!-- a- --b -->`, false},
+		{`<!-- a --->`, false},
+	}
+	for _, tt := range tests {
+		vw := NewValidatingWriter(new(strings.Builder))
+		_, err := vw.Write([]byte(tt.markup))
+		if err == nil {
+			err = vw.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error %v, want ok %v", tt.markup, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package shpdeck
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/jonas-p/go-shp"
+)
+
+// record is a buffered shape and its row
+type record struct {
+	row   int
+	shape shp.Shape
+	z     float64
+}
+
+// recordBuffer replays buffered records as a records source
+type recordBuffer struct {
+	recs   []record
+	i      int
+	err    error
+	reader *shp.Reader // the attributes of the records, if known
+}
+
+func (b *recordBuffer) Next() bool {
+	if b.i >= len(b.recs) {
+		return false
+	}
+	b.i++
+	return true
+}
+
+func (b *recordBuffer) Shape() (int, shp.Shape) {
+	r := b.recs[b.i-1]
+	return r.row, r.shape
+}
+
+func (b *recordBuffer) Err() error {
+	return b.err
+}
+
+// zordered reads every record of r and returns them sorted ascending by
+// the numeric field, keeping input order among equal values. Records
+// without a numeric value sort as zero. It returns r unchanged when it has
+// no such field.
+func zordered(r records, field string, parse func(string) (float64, bool)) records {
+	sr := attributes(r)
+	if sr == nil {
+		return r
+	}
+	fi := fieldIndex(sr, field)
+	if fi < 0 {
+		return r
+	}
+	b := &recordBuffer{reader: sr}
+	for r.Next() {
+		n, s := r.Shape()
+		z, _ := fieldValue(sr, n, fi, parse)
+		b.recs = append(b.recs, record{row: n, shape: s, z: z})
+	}
+	b.err = r.Err()
+	slices.SortStableFunc(b.recs, func(x, y record) int {
+		return cmp.Compare(x.z, y.z)
+	})
+	return b
+}
+
+// keyordered reads every record of r and returns them sorted ascending by
+// the field, as numbers when every value parses and as text otherwise,
+// keeping input order among equal keys. It returns r unchanged when it has
+// no such field.
+func keyordered(r records, field string, parse func(string) (float64, bool)) records {
+	sr := attributes(r)
+	if sr == nil {
+		return r
+	}
+	fi := fieldIndex(sr, field)
+	if fi < 0 {
+		return r
+	}
+	if parse == nil {
+		parse = ParseValue
+	}
+	b := &recordBuffer{reader: sr}
+	keys := map[int]string{}
+	numeric := true
+	for r.Next() {
+		n, s := r.Shape()
+		k := strings.Trim(sr.ReadAttribute(n, fi), " \x00")
+		v, ok := parse(k)
+		numeric = numeric && ok
+		keys[n] = k
+		b.recs = append(b.recs, record{row: n, shape: s, z: v})
+	}
+	b.err = r.Err()
+	slices.SortStableFunc(b.recs, func(x, y record) int {
+		if numeric {
+			return cmp.Compare(x.z, y.z)
+		}
+		return strings.Compare(keys[x.row], keys[y.row])
+	})
+	return b
+}